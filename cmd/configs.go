@@ -0,0 +1,241 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// defaultIncludes are the patterns picking configs out of directories when
+// no --include is given.
+var defaultIncludes = []string{"*.yaml", "*.yml", "*.json"}
+
+// expandConfigs turns config arguments into the list of files to validate,
+// in order and without duplicates. An argument is a file, a directory
+// searched recursively for files matching includes, or a glob pattern where
+// "**" matches any number of directories. Files and directories matching
+// excludes are left out. Patterns that match nothing and directories that
+// cannot be read stay in configs, in order, with the *validator.ConfigError
+// to report for them in failed, so that the other configs are still
+// validated.
+func expandConfigs(args []string, includes []string, excludes []string) (configs []string, failed map[string]error, err error) {
+	if len(includes) == 0 {
+		includes = defaultIncludes
+	}
+
+	failed = map[string]error{}
+	seen := map[string]bool{}
+
+	add := func(name string) {
+		if !seen[name] && !matchAny(excludes, name) {
+			seen[name] = true
+			configs = append(configs, name)
+		}
+	}
+
+	for _, arg := range args {
+		if hasMeta(arg) {
+			matches, err := globFiles(arg, excludes)
+			if _, ok := err.(*validator.ConfigError); ok {
+				add(arg)
+				failed[arg] = err
+				continue
+			} else if err != nil {
+				return nil, nil, err
+			}
+
+			if len(matches) == 0 {
+				add(arg)
+				failed[arg] = &validator.ConfigError{Config: arg, Err: fmt.Errorf("no config matches %s", arg)}
+				continue
+			}
+
+			for _, match := range matches {
+				add(match)
+			}
+			continue
+		}
+
+		dir := arg
+		if src, err := validator.ParseSource(arg); err == nil && !src.IsStdin() && !src.IsURL() {
+			// "~/configs" or file://configs
+			dir = src.Location
+		}
+
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			// let validation report configs that cannot be read
+			add(arg)
+			continue
+		}
+
+		files, err := walkFiles(dir, excludes, func(name string) bool {
+			return matchAny(includes, name)
+		})
+		if err != nil {
+			add(arg)
+			failed[arg] = &validator.ConfigError{Config: arg, Err: fmt.Errorf("reading config directory %s: %v", arg, err)}
+			continue
+		}
+
+		for _, file := range files {
+			add(file)
+		}
+	}
+
+	return configs, failed, nil
+}
+
+// globFiles returns the files matching pattern, sorted.
+func globFiles(pattern string, excludes []string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, &validator.UsageError{Err: fmt.Errorf("invalid pattern %s: %v", pattern, err)}
+		}
+
+		var files []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+
+		return files, nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, &validator.UsageError{Err: fmt.Errorf("invalid pattern %s: %v", pattern, err)}
+	}
+
+	// walk from the longest directory prefix free of wildcards
+	root := "."
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, segment := range segments {
+		if hasMeta(segment) {
+			if i > 0 {
+				root = filepath.FromSlash(strings.Join(segments[:i], "/"))
+				if root == "" {
+					root = "/"
+				}
+			}
+			break
+		}
+	}
+
+	files, err := walkFiles(root, excludes, func(name string) bool {
+		return matchPath(pattern, name)
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, &validator.ConfigError{Config: pattern, Err: fmt.Errorf("expanding %s: %v", pattern, err)}
+	}
+
+	return files, nil
+}
+
+// walkFiles returns the files below root accepted by keep, in lexical
+// order, skipping whatever matches excludes.
+func walkFiles(root string, excludes []string, keep func(name string) bool) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name != root && matchAny(excludes, name) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !info.IsDir() && keep(name) {
+			files = append(files, name)
+		}
+
+		return nil
+	})
+
+	sort.Strings(files)
+
+	return files, err
+}
+
+// matchAny reports whether name matches one of patterns, see matchPath.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPath reports whether name matches pattern. Patterns without a slash
+// match the base name, e.g. "*.yaml"; others the whole path, where "**"
+// stands for any number of directories, e.g. "clusters/**/*.yaml".
+func matchPath(pattern string, name string) bool {
+	pattern = filepath.ToSlash(pattern)
+	name = filepath.ToSlash(filepath.Clean(name))
+
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+
+	return matchSegments(strings.Split(path.Clean(pattern), "/"), strings.Split(name, "/"))
+}
+
+// matchSegments matches the segments of a path against those of a pattern.
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// hasMeta reports whether s holds glob wildcards.
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
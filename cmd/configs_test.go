@@ -0,0 +1,120 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"*.yaml", "clusters/a/config.yaml", true},
+		{"*.yaml", "clusters/a/config.json", false},
+		{"clusters/**/*.yaml", "clusters/config.yaml", true},
+		{"clusters/**/*.yaml", "clusters/a/b/config.yaml", true},
+		{"clusters/**/*.yaml", "other/a/config.yaml", false},
+		{"**/testdata/**", "a/testdata/b/config.yaml", true},
+		{"**/testdata/**", "a/b/config.yaml", false},
+		{"clusters/*/config.yaml", "./clusters/a/config.yaml", true},
+	}
+
+	for _, test := range tests {
+		if match := matchPath(test.pattern, test.name); match != test.match {
+			t.Errorf("%s against %s: expected %v, got %v", test.pattern, test.name, test.match, match)
+		}
+	}
+}
+
+func TestExpandConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonsvalidator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{
+		"a.yaml",
+		"notes.txt",
+		"clusters/one/config.yaml",
+		"clusters/two/config.json",
+		"clusters/testdata/broken.yaml",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("a: 1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	join := func(names ...string) []string {
+		var paths []string
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		includes []string
+		excludes []string
+		expect   []string
+	}{
+		{"directory", join(""), nil, nil,
+			join("a.yaml", "clusters/one/config.yaml", "clusters/testdata/broken.yaml", "clusters/two/config.json")},
+		{"directory with exclude", join(""), nil, []string{"testdata"},
+			join("a.yaml", "clusters/one/config.yaml", "clusters/two/config.json")},
+		{"directory with include", join(""), []string{"*.json"}, nil,
+			join("clusters/two/config.json")},
+		{"glob", join("clusters/**/*.yaml"), nil, []string{"**/testdata/**"},
+			join("clusters/one/config.yaml")},
+		{"files and duplicates", join("notes.txt", "a.yaml", "*.yaml"), nil, nil,
+			join("notes.txt", "a.yaml")},
+	}
+
+	for _, test := range tests {
+		configs, failed, err := expandConfigs(test.args, test.includes, test.excludes)
+		if err != nil || len(failed) > 0 {
+			t.Errorf("%s: unexpected error: %v %v", test.name, err, failed)
+			continue
+		}
+
+		if !reflect.DeepEqual(configs, test.expect) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expect, configs)
+		}
+	}
+
+	// a pattern matching nothing fails on its own, in place
+	configs, failed, err := expandConfigs(join("**/*.toml", "a.yaml"), nil, nil)
+	if err != nil || !reflect.DeepEqual(configs, join("**/*.toml", "a.yaml")) {
+		t.Errorf("expected the pattern to stay before a.yaml, got %v (%v)", configs, err)
+	}
+	if _, ok := failed[join("**/*.toml")[0]].(*validator.ConfigError); !ok || len(failed) != 1 {
+		t.Errorf("expected a *validator.ConfigError for the pattern matching nothing, got %v", failed)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+	"github.com/spf13/cobra"
+)
+
+// formatsCmd lists the formats a schema may name in its "format" keywords.
+var formatsCmd = &cobra.Command{
+	Use:   "formats",
+	Short: "List the formats schemas can use.",
+	Long: `List the values the "format" keyword accepts, with the JSON types each
+applies to, a description, and example values it accepts and rejects.
+Values of other types are not checked against the format.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doFormats(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(formatsCmd)
+}
+
+// doFormats writes the default and built-in formats as a table sorted by name.
+func doFormats(out io.Writer) error {
+	formats := append(validator.DefaultFormats().Formats(), validator.BuiltinFormats()...)
+	sort.Slice(formats, func(i, j int) bool { return formats[i].Name < formats[j].Name })
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPES\tDESCRIPTION\tEXAMPLES\tREJECTED")
+	for _, format := range formats {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", format.Name, strings.Join(format.Types(), ","), format.Description,
+			quoteAll(format.Examples), quoteAll(format.Rejected))
+	}
+
+	return w.Flush()
+}
+
+// quoteAll lists values as Go strings, so that commas and empty values can
+// be told apart.
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+
+	return strings.Join(quoted, ", ")
+}
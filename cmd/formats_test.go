@@ -0,0 +1,45 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	var out bytes.Buffer
+	if err := doFormats(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !strings.HasPrefix(lines[0], "NAME") {
+		t.Errorf("expected a header, got %q", lines[0])
+	}
+
+	for _, name := range []string{"cidr", "semver", "ipv4", "uri"} {
+		found := false
+		for _, line := range lines[1:] {
+			if strings.HasPrefix(line, name+" ") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %s to be listed, got:\n%s", name, out.String())
+		}
+	}
+}
@@ -18,9 +18,32 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/samsung-cnct/jsonsvalidator/validator"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes of the command. They are part of its interface, see README.md.
+const (
+	// ExitValid means every config validated.
+	ExitValid = 0
+
+	// ExitInvalid means a config was read but does not satisfy the schema.
+	ExitInvalid = 1
+
+	// ExitUsage means the command line was wrong: unknown or missing flags.
+	ExitUsage = 2
+
+	// ExitSchema means the schema, or a document it references, could not
+	// be loaded or compiled.
+	ExitSchema = 3
+
+	// ExitConfig means a config could not be read or parsed.
+	ExitConfig = 4
+
+	// ExitInternal means the command itself failed unexpectedly.
+	ExitInternal = 5
+)
+
 var cfgFile string
 var runtimeCommandName = os.Args[0]
 
@@ -28,14 +51,83 @@ var runtimeCommandName = os.Args[0]
 var RootCmd = &cobra.Command{
 	Use:   runtimeCommandName,
 	SilenceErrors: true,
+	SilenceUsage: true,
 	Short: "validate JSON config against the JSON schema validator (spec v4).",
 }
 
+// internalError marks a failure of the command itself rather than of its
+// input, e.g. failing to encode a result.
+type internalError struct {
+	err error
+}
+
+func (e *internalError) Error() string {
+	return e.err.Error()
+}
+
+// exitCode maps an error returned by a command to the exit code of the
+// process. Errors the commands do not classify come from cobra parsing the
+// command line, and are usage errors.
+func exitCode(err error) int {
+	switch err.(type) {
+	case nil:
+		return ExitValid
+	case *validator.InvalidError:
+		return ExitInvalid
+	case *validator.UsageError:
+		return ExitUsage
+	case *validator.SchemaError:
+		return ExitSchema
+	case *validator.ConfigError:
+		return ExitConfig
+	case *internalError:
+		return ExitInternal
+	case *rulesTestError:
+		return ExitInvalid
+	default:
+		return ExitUsage
+	}
+}
+
+// severity ranks exit codes from the best outcome to the worst one.
+var severity = map[int]int{
+	ExitValid:    0,
+	ExitInvalid:  1,
+	ExitConfig:   2,
+	ExitSchema:   3,
+	ExitUsage:    4,
+	ExitInternal: 5,
+}
+
+// worstError returns the error of errs with the worst outcome, or nil when
+// all of them are nil.
+func worstError(errs []error) error {
+	var worst error
+
+	for _, err := range errs {
+		if severity[exitCode(err)] > severity[exitCode(worst)] {
+			worst = err
+		}
+	}
+
+	return worst
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Schema, config and validation failures are already part of the printed
+// result, so only usage and internal errors are reported on stderr.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
+	err := RootCmd.Execute()
+	code := exitCode(err)
+
+	switch code {
+	case ExitUsage:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%v --help' for usage.\n", RootCmd.CommandPath())
+	case ExitInternal:
+		fmt.Fprintln(os.Stderr, "Error:", err)
 	}
+
+	os.Exit(code)
 }
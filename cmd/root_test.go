@@ -0,0 +1,169 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samsung-cnct/jsonsvalidator/report"
+)
+
+func TestExitCodes(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := filepath.Join(cwd, "test_schemas")
+	configs := filepath.Join(cwd, "test_configs")
+
+	tests := []struct {
+		name   string
+		schema string
+		config string
+		expect int
+	}{
+		{"valid", "validate_cidr.json", "cidr_valid.yaml", ExitValid},
+		{"invalid", "validate_cidr.json", "cidr_invalid.yaml", ExitInvalid},
+		{"missing schema", "missing.json", "cidr_valid.yaml", ExitSchema},
+		{"unresolvable schema", "full_config.json", "config_valid.yaml", ExitSchema},
+		{"missing config", "validate_cidr.json", "missing.yaml", ExitConfig},
+		{"unparsable config", "validate_cidr.json", "unparsable.yaml", ExitConfig},
+		{"invalid document in a stream", "validate_cidr.json", "cidr_stream_invalid.yaml", ExitInvalid},
+	}
+
+	for _, test := range tests {
+		err := doValidate(ioutil.Discard, filepath.Join(schemas, test.schema), []string{filepath.Join(configs, test.config)}, nil)
+
+		if code := exitCode(err); code != test.expect {
+			t.Errorf("%s: expected exit code %d, got %d (%v)", test.name, test.expect, code, err)
+		}
+	}
+
+	singleDocument = true
+	err = doValidate(ioutil.Discard, filepath.Join(schemas, "validate_cidr.json"), []string{filepath.Join(configs, "cidr_stream_valid.yaml")}, nil)
+	singleDocument = false
+
+	if code := exitCode(err); code != ExitConfig {
+		t.Errorf("--single-document: expected exit code %d for a stream, got %d (%v)", ExitConfig, code, err)
+	}
+
+	rulesFiles = []string{filepath.Join(cwd, "missing_rules.yaml")}
+	err = doValidate(ioutil.Discard, filepath.Join(schemas, "validate_cidr.json"), []string{filepath.Join(configs, "cidr_valid.yaml")}, nil)
+	rulesFiles = nil
+
+	if code := exitCode(err); code != ExitSchema {
+		t.Errorf("--rules: expected exit code %d for a missing rules file, got %d (%v)", ExitSchema, code, err)
+	}
+
+	// several configs exit with the worst outcome
+	var several []string
+	for _, config := range []string{"cidr_valid.yaml", "missing.yaml", "cidr_invalid.yaml"} {
+		several = append(several, filepath.Join(configs, config))
+	}
+
+	var out bytes.Buffer
+	err = doValidate(&out, filepath.Join(schemas, "validate_cidr.json"), several, nil)
+
+	if code := exitCode(err); code != ExitConfig {
+		t.Errorf("several configs: expected exit code %d, got %d (%v)", ExitConfig, code, err)
+	}
+
+	if lines := strings.Count(out.String(), "\n"); lines != 3 {
+		t.Errorf("several configs: expected 3 results, got %d:\n%s", lines, out.String())
+	}
+
+	// a pattern matching nothing is reported in place, and the others are
+	// still validated
+	expanded, failed, err := expandConfigs([]string{filepath.Join("test_configs", "nomatch", "*.yaml"), filepath.Join("test_configs", "cidr_invalid.yaml")}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err = doValidate(&out, filepath.Join(schemas, "validate_cidr.json"), expanded, failed)
+
+	if code := exitCode(err); code != ExitConfig {
+		t.Errorf("pattern matching nothing: expected exit code %d, got %d (%v)", ExitConfig, code, err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 ||
+		!strings.Contains(lines[0], "no config matches") || !strings.Contains(lines[1], "cidr_invalid.yaml") {
+		t.Errorf("pattern matching nothing: expected the pattern then the other config reported, got:\n%s", out.String())
+	}
+
+	// every format reports the same outcome
+	templateText = "{{.Config}}"
+	defer func() { templateText = "" }()
+
+	for _, name := range report.Formats() {
+		var out bytes.Buffer
+
+		format = name
+		err = doValidate(&out, filepath.Join(schemas, "validate_cidr.json"), several, nil)
+		format = "json"
+
+		if code := exitCode(err); code != ExitConfig {
+			t.Errorf("--format %s: expected exit code %d, got %d (%v)", name, ExitConfig, code, err)
+		}
+
+		if !strings.Contains(out.String(), "cidr_invalid.yaml") {
+			t.Errorf("--format %s: expected the invalid config to be reported, got:\n%s", name, out.String())
+		}
+	}
+
+	// a template failing to execute is a usage error
+	format, templateText = "template", "{{.Nope}}"
+	err = doValidate(ioutil.Discard, filepath.Join(schemas, "validate_cidr.json"), several, nil)
+	format, templateText = "json", "{{.Config}}"
+
+	if code := exitCode(err); code != ExitUsage {
+		t.Errorf("--template: expected exit code %d for a failing template, got %d (%v)", ExitUsage, code, err)
+	}
+
+	// a config piped in, against a relative schema path
+	data, err := ioutil.ReadFile(filepath.Join(configs, "cidr_invalid.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdin = bytes.NewReader(data)
+	defer func() { stdin = os.Stdin }()
+
+	out.Reset()
+	err = doValidate(&out, filepath.Join("test_schemas", "validate_cidr.json"), []string{"-"}, nil)
+
+	if code := exitCode(err); code != ExitInvalid {
+		t.Errorf("stdin: expected exit code %d, got %d (%v)", ExitInvalid, code, err)
+	}
+
+	if !strings.Contains(out.String(), `"config":"-"`) {
+		t.Errorf("stdin: expected the config to be reported as -, got:\n%s", out.String())
+	}
+
+	if code := exitCode(errors.New("unknown flag: --bogus")); code != ExitUsage {
+		t.Errorf("expected command line errors to exit with %d, got %d", ExitUsage, code)
+	}
+
+	if code := exitCode(&internalError{err: errors.New("boom")}); code != ExitInternal {
+		t.Errorf("expected internal errors to exit with %d, got %d", ExitInternal, code)
+	}
+}
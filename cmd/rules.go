@@ -0,0 +1,160 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+	"github.com/spf13/cobra"
+)
+
+// rulesCmd groups the commands working on rules files.
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Work with policy rules files.",
+	Long: `Rules files hold policy rules that do not fit JSON Schema, such as "the
+sum of node pool counts is at most 100". Each rule has an id, a severity, a
+selector picking the nodes it applies to, a boolean expression over each of
+them and the root of the document, and a message template. Check configs
+against them with 'validate --rules'.`,
+}
+
+// rulesTestCmd runs the tests of rules files.
+var rulesTestCmd = &cobra.Command{
+	Use:   "test <rules>...",
+	Short: "Run the tests of rules files against their fixtures.",
+	Long: `Validate the fixture of every test listed under "tests" in the rules
+files with the rules only. A test expecting success passes when its fixture
+breaks no rule; a test expecting fail passes when it breaks some, exactly
+those listed under "rules" if any are. Fixtures are found relative to the
+rules file.`,
+	Example: "rules test rules.yaml",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("no rules file given")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return doRulesTest(cmd.OutOrStdout(), args)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(rulesCmd)
+	rulesCmd.AddCommand(rulesTestCmd)
+}
+
+// rulesTestError reports rules tests that did not pass.
+type rulesTestError struct {
+	failed int
+}
+
+func (e *rulesTestError) Error() string {
+	return fmt.Sprintf("%d rules test(s) failed", e.failed)
+}
+
+// doRulesTest runs the tests of every rules file and writes their outcomes
+// to out, followed by a summary. The returned error reflects the worst
+// outcome: a *validator.SchemaError for a rules file that cannot be read, a
+// *validator.ConfigError for a fixture, or a *rulesTestError.
+func doRulesTest(out io.Writer, files []string) error {
+	var buf bytes.Buffer
+	var errs []error
+	passed, failed, broken := 0, 0, 0
+
+	for _, file := range files {
+		results, err := runRulesTests(file)
+		if err != nil {
+			errs = append(errs, err)
+			fmt.Fprintf(&buf, "ERROR %s\n  %v\n", file, err)
+			continue
+		}
+
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				broken++
+				errs = append(errs, result.Err)
+				fmt.Fprintf(&buf, "ERROR %s: %s\n  %v\n", file, result.Test.Name, result.Err)
+			case result.Passed:
+				passed++
+				fmt.Fprintf(&buf, "PASS  %s: %s\n", file, result.Test.Name)
+			default:
+				failed++
+				fmt.Fprintf(&buf, "FAIL  %s: %s\n  %s\n", file, result.Test.Name, expectation(result))
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d %s: %d passed, %d failed", passed+failed+broken,
+		plural(passed+failed+broken, "test", "tests"), passed, failed)
+	if broken > 0 {
+		summary += fmt.Sprintf(", %d not run", broken)
+	}
+	fmt.Fprintf(&buf, "\n%s\n", summary)
+
+	if _, err := buf.WriteTo(out); err != nil {
+		return &internalError{err: err}
+	}
+
+	if failed > 0 {
+		errs = append(errs, &rulesTestError{failed: failed})
+	}
+
+	return worstError(errs)
+}
+
+// runRulesTests loads the rules file and runs its tests. A rules file that
+// cannot be read is reported as a *validator.SchemaError.
+func runRulesTests(file string) ([]validator.RuleTestResult, error) {
+	rules, err := validator.LoadRules(file)
+	if err != nil {
+		return nil, &validator.SchemaError{Schema: file, Err: err}
+	}
+
+	return rules.RunTests(context.Background())
+}
+
+// expectation tells how the fixture of a failed test let it down.
+func expectation(result validator.RuleTestResult) string {
+	broken := "none"
+	if len(result.Broken) > 0 {
+		broken = strings.Join(result.Broken, ", ")
+	}
+
+	switch {
+	case result.Test.Expect == "success":
+		return "expected no rule to be broken, broken: " + broken
+	case len(result.Test.Rules) == 0:
+		return "expected a rule to be broken, broken: none"
+	}
+
+	return fmt.Sprintf("expected %s to be broken, broken: %s", strings.Join(result.Test.Rules, ", "), broken)
+}
+
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return one
+	}
+
+	return many
+}
@@ -0,0 +1,92 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRulesTest(t *testing.T) {
+	var out bytes.Buffer
+	if err := doRulesTest(&out, []string{"test_rules/kraken.yaml"}); err != nil {
+		t.Fatalf("%v:\n%s", err, out.String())
+	}
+
+	if !strings.HasSuffix(out.String(), "\n2 tests: 2 passed, 0 failed\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	dir, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	failing := filepath.Join(dir, "failing.yaml")
+	config, _ := filepath.Abs("test_configs/kraken_valid.yaml")
+	rules := "rules: [{id: one-cluster, expression: 'len($.deployment.clusters) > 1'}]\n" +
+		"tests: [{name: valid, config: '" + config + "', expect: success}]\n"
+	if err := ioutil.WriteFile(failing, []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err = doRulesTest(&out, []string{failing})
+	if code := exitCode(err); code != ExitInvalid {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalid, code, err)
+	}
+	if !strings.Contains(out.String(), "FAIL  "+failing+": valid\n  expected no rule to be broken, broken: one-cluster\n") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = doRulesTest(&out, []string{failing, filepath.Join(dir, "missing.yaml")})
+	if code := exitCode(err); code != ExitSchema {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitSchema, code, err)
+	}
+}
+
+func TestValidateWithRules(t *testing.T) {
+	rulesFiles = []string{"test_rules/kraken.yaml"}
+	defer func() { rulesFiles = nil }()
+
+	results, err := validateFile("test_schemas/kraken/config.json", "test_configs/kraken_rules_invalid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var broken []string
+	for _, e := range results[0].Exceptions {
+		broken = append(broken, e.Rule+" "+e.Severity)
+	}
+
+	expected := "max-nodes error,etcd-odd-count warning,aws-instance-types error"
+	if results[0].IsValid || strings.Join(broken, ",") != expected {
+		t.Errorf("expected %s, got %v %v", expected, results[0].IsValid, broken)
+	}
+
+	results, err = validateFile("test_schemas/kraken/config.json", "test_configs/kraken_valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].IsValid || len(results[0].Exceptions) != 0 {
+		t.Errorf("expected the valid config to break no rule, got %+v", results[0].Exceptions)
+	}
+}
@@ -16,22 +16,55 @@ package cmd
 
 import (
 	"fmt"
-	"regexp"
+	"io/ioutil"
+	"runtime"
+	"time"
 
+	"github.com/samsung-cnct/jsonsvalidator/report"
+	"github.com/samsung-cnct/jsonsvalidator/validator"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 var configFile string
 var schemaFile string
+var httpTimeout time.Duration
+var outputVersion int
+var singleDocument bool
+var includes []string
+var excludes []string
+var jobs int
+var format string
+var colorMode string
+var templateText string
+var templateFile string
+var outputStructure string
+var rulesFiles []string
 
 
 // validateCmd represents the validate command
 var validateCmd = &cobra.Command{
-	Use:   "validate",
+	Use:   "validate [configs...]",
 	Short: "Set config file to be validated.",
-	Long: "Validate a config (--config) file against a JSON schema (--schema)",
-	Example: "validate  --schema <schema> --config <instance/config file>",
+	Long: `Validate config files against a JSON schema (--schema). The schema, and
+every document it references, may be written in JSON or YAML.
+
+Configs are given with --config and as arguments. An argument may be a file,
+a directory searched recursively for files matching --include, or a glob
+pattern where "**" matches any number of directories.
+
+Paths may be relative to the working directory, start with "~" or be file://
+URIs, and "-" reads the schema or a config from stdin.
+
+Policy rules that do not fit JSON Schema are checked with --rules; their
+violations are reported along with the errors of the schema, and only rules
+of severity error make a config invalid.`,
+	Example: "validate  --schema <schema> --config <instance/config file>\n" +
+		"validate  --schema <schema> a.yaml 'clusters/**/*.yaml' dir/ --exclude 'testdata'\n" +
+		"validate  --schema <schema> --format sarif configs/ > results.sarif\n" +
+		"validate  --schema <schema> --rules rules.yaml configs/\n" +
+		"helm template chart/ | validate --schema ~/schemas/<schema> -\n" +
+		"validate  --schema <schema> --format template --template '{{.Config}}: {{len .Exceptions}} errors' configs/",
 	PreRunE: func(cmd *cobra.Command, args []string) (err error) {
 		if err = CheckRequiredFlags(cmd.Flags()); err != nil {
 			return err
@@ -41,14 +74,75 @@ var validateCmd = &cobra.Command{
 			return err
 		}
 
-		if err = RequiredFlagHasArgs("config", configFile); err != nil {
+		if configFile == "" && len(args) == 0 {
+			return fmt.Errorf("no config given; use --config or pass configs as arguments")
+		}
+
+		if _, err = validator.ParseSource(schemaFile); err != nil {
+			return fmt.Errorf("invalid --schema: %v", err)
+		}
+
+		configStdin := configFile == "-"
+		for _, arg := range args {
+			configStdin = configStdin || arg == "-"
+		}
+
+		if schemaFile == "-" && configStdin {
+			return fmt.Errorf("the schema and a config cannot both be read from stdin (-)")
+		}
+
+		if jobs < 1 {
+			return fmt.Errorf("--jobs must be at least 1, got %d", jobs)
+		}
+
+		if templateText != "" && templateFile != "" {
+			return fmt.Errorf("--template and --template-file cannot be used together")
+		}
+
+		if templateFile != "" {
+			text, readErr := ioutil.ReadFile(templateFile)
+			if readErr != nil {
+				return fmt.Errorf("reading template: %v", readErr)
+			}
+			templateText = string(text)
+		}
+
+		if templateText != "" && format != "template" {
+			return fmt.Errorf("--template and --template-file need --format template")
+		}
+
+		if outputStructure != "" && format != "json" {
+			return fmt.Errorf("--output-structure needs --format json")
+		}
+
+		opts := report.Options{Template: templateText, OutputStructure: outputStructure}
+		if _, err = report.New(format, ioutil.Discard, opts); err != nil {
 			return err
 		}
 
+		if colorMode != "auto" && colorMode != "always" && colorMode != "never" {
+			return fmt.Errorf("--color must be auto, always or never, got %q", colorMode)
+		}
+
+		if outputVersion < validator.OutputV1 || outputVersion > validator.LatestOutput {
+			return fmt.Errorf("unsupported output version %d; supported versions are %d to %d",
+				outputVersion, validator.OutputV1, validator.LatestOutput)
+		}
+
 		return err
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return doValidate(schemaFile, configFile)
+		configArgs := args
+		if configFile != "" {
+			configArgs = append([]string{configFile}, args...)
+		}
+
+		configs, failed, err := expandConfigs(configArgs, includes, excludes)
+		if err != nil {
+			return err
+		}
+
+		return doValidate(cmd.OutOrStdout(), schemaFile, configs, failed)
 	},
 }
 
@@ -60,7 +154,71 @@ func init() {
 		"schema",
 		"s",
 		"",
-		"schema file to validate against.",
+		"schema to validate against: a file, a file:// URI, an http(s) URL or - for stdin.",
+	)
+
+	validateCmd.PersistentFlags().StringSliceVar(
+		&rulesFiles,
+		"rules",
+		nil,
+		"rules files holding policy rules checked after the schema; see 'rules test'.",
+	)
+
+	validateCmd.PersistentFlags().DurationVar(
+		&httpTimeout,
+		"http-timeout",
+		validator.DefaultHTTPTimeout,
+		"timeout for each request fetching a remote schema.",
+	)
+
+	validateCmd.PersistentFlags().StringVarP(
+		&format,
+		"format",
+		"f",
+		"json",
+		"output format: json, text, junit, sarif, github (Actions annotations), gitlab (Code Quality) or template.",
+	)
+
+	validateCmd.PersistentFlags().StringVar(
+		&templateText,
+		"template",
+		"",
+		"Go text/template rendered for each result with --format template, e.g. '{{.Config}}: {{len .Exceptions}} errors'.",
+	)
+
+	validateCmd.PersistentFlags().StringVar(
+		&templateFile,
+		"template-file",
+		"",
+		"file holding the template of --format template.",
+	)
+
+	validateCmd.PersistentFlags().StringVar(
+		&colorMode,
+		"color",
+		"auto",
+		"colour the text format: auto (when writing to a terminal and NO_COLOR is unset), always or never.",
+	)
+
+	validateCmd.PersistentFlags().IntVar(
+		&outputVersion,
+		"output-version",
+		validator.OutputV1,
+		"version of the JSON result layout of the json format; 2 adds error types, pointers, values and schema locations.",
+	)
+
+	validateCmd.PersistentFlags().StringVar(
+		&outputStructure,
+		"output-structure",
+		"",
+		"lay JSON results out as a standard JSON Schema output structure instead: flag, basic, detailed or verbose.",
+	)
+
+	validateCmd.PersistentFlags().BoolVar(
+		&singleDocument,
+		"single-document",
+		false,
+		"fail when the config holds more than one YAML document.",
 	)
 
 	validateCmd.PersistentFlags().StringVarP(
@@ -68,7 +226,29 @@ func init() {
 		"config",
 		"c",
 		"",
-		"config file to be validated.",
+		"config file to be validated, or - for stdin.",
+	)
+
+	validateCmd.PersistentFlags().IntVarP(
+		&jobs,
+		"jobs",
+		"j",
+		runtime.NumCPU(),
+		"number of configs validated in parallel; results keep the order of the configs.",
+	)
+
+	validateCmd.PersistentFlags().StringSliceVar(
+		&includes,
+		"include",
+		nil,
+		"patterns of the files validated in config directories (default *.yaml,*.yml,*.json).",
+	)
+
+	validateCmd.PersistentFlags().StringSliceVar(
+		&excludes,
+		"exclude",
+		nil,
+		"patterns of the files and directories to skip; patterns with a / match the whole path.",
 	)
 }
 
@@ -105,12 +285,10 @@ func CheckRequiredFlags(flags *pflag.FlagSet) error {
 	return nil
 }
 
-// RequiredFlagHasArgs Check if required flags have args
+// RequiredFlagHasArgs Check if required flags have args. Whether the
+// argument names something usable is left to the command.
 func RequiredFlagHasArgs(flag string, arg string) error {
-	re := regexp.MustCompile(arg)
-	match := re.FindStringSubmatch(flag)
-
-	if len(arg) == 0 || len(match) > 0 {
+	if len(arg) == 0 {
 		return fmt.Errorf("flag `%s` requires an arugment", flag)
 	}
 
@@ -15,144 +15,224 @@
 package cmd
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
+	"strings"
 
-	"github.com/ghodss/yaml"
-	"github.com/xeipuuv/gojsonschema"
+	"github.com/samsung-cnct/jsonsvalidator/report"
+	"github.com/samsung-cnct/jsonsvalidator/validator"
 )
 
-// fileContentsNormalizer injests a file, reads the contents, & returns
-// the content in JSON. It can take YAML or JSON data. If it's JSON,
-// it's just returned. If it's YAML, it's validated, JSONized, and
-// then returned. If the YAML is not valid then the application
-// exits with an error.
-func fileContentsNormalizer(configFile string) ([]byte, error) {
-	fileContents, err := ioutil.ReadFile(configFile)
-	if err != nil {
-		return nil, err
+const (
+	// envHTTPAuthorization holds the Authorization header sent when fetching
+	// a remote schema, e.g. "Bearer <token>".
+	envHTTPAuthorization = "JSONSVALIDATOR_HTTP_AUTHORIZATION"
+
+	// envHTTPHeaders holds extra headers sent when fetching a remote schema,
+	// as "Name: value" pairs separated by newlines or semicolons.
+	envHTTPHeaders = "JSONSVALIDATOR_HTTP_HEADERS"
+)
+
+// stdin is read for a schema or config given as "-".
+var stdin io.Reader = os.Stdin
+
+// httpHeadersFromEnv turns the envHTTPAuthorization and envHTTPHeaders
+// environment variables into validator options.
+func httpHeadersFromEnv() ([]validator.Option, error) {
+	var opts []validator.Option
+
+	if auth := os.Getenv(envHTTPAuthorization); auth != "" {
+		opts = append(opts, validator.WithHTTPHeader("Authorization", auth))
 	}
 
-	if isJSON(fileContents) {
-		return fileContents, nil
+	headers := strings.FieldsFunc(os.Getenv(envHTTPHeaders), func(r rune) bool {
+		return r == '\n' || r == ';'
+	})
+
+	for _, header := range headers {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q in %s; expected \"Name: value\"", header, envHTTPHeaders)
+		}
+
+		opts = append(opts, validator.WithHTTPHeader(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])))
 	}
 
-	jsonContents, err := yaml.YAMLToJSON(fileContents)
+	return opts, nil
+}
+
+// newValidator builds the validator checking configs against schemaFile,
+// which may be a path, a file:// URI, an http(s) URL or "-" for stdin.
+func newValidator(schemaFile string) (*validator.Validator, error) {
+	headers, err := httpHeadersFromEnv()
 	if err != nil {
-		return nil, err
+		return nil, &validator.UsageError{Err: err}
 	}
 
-	return jsonContents, nil
-}
+	opts := append([]validator.Option{
+		validator.WithSchema(schemaFile),
+		validator.WithHTTPTimeout(httpTimeout),
+		validator.WithStdin(stdin),
+	}, headers...)
 
-func isJSON(b []byte) bool {
-	var js interface{}
-	return json.Unmarshal(b, &js) == nil
-}
+	if singleDocument {
+		opts = append(opts, validator.WithSingleDocument())
+	}
 
-// fileExists check if a file exists on the system
-func fileExists(name string) (bool, error) {
-	file, err := os.Stat(name)
-	if err != nil {
-		return !os.IsNotExist(err), err
+	for _, rules := range rulesFiles {
+		opts = append(opts, validator.WithRulesFile(rules))
 	}
 
-	if file.IsDir() {
-		return false,  errors.New("invalid file specified; requested file is a directory, not a file")
+	if needsCauses() {
+		opts = append(opts, validator.WithCauses())
 	}
 
-	return true, nil
+	return validator.New(opts...)
 }
 
-// JSONDataRespValidate will is the main function that performs validation. However,
-// this function always returns a data structure `result` of type struct containing
-// data for the validation.
-func JSONDataRespValidate(schemaFile string, configFile string) (jsonOutput []byte, err error) {
-	if _, err := fileExists(configFile); err != nil {
-		return nil, err
+// needsCauses reports whether the output shows the errors of every anyOf
+// and oneOf branch, which cost validating each branch again.
+func needsCauses() bool {
+	if format != "json" {
+		return false
 	}
 
-	if _, err := fileExists(schemaFile); err != nil {
-		return nil, err
+	return outputStructure != "" && outputStructure != validator.OutputFlag ||
+		outputStructure == "" && outputVersion >= validator.OutputV2
+}
+
+// newReporter returns the reporter writing results to out in the --format
+// of the command.
+func newReporter(out io.Writer, schemaFile string) (report.Reporter, error) {
+	useColor := colorMode == "always" || colorMode == "auto" && report.ColorEnabled(out)
+
+	// report the schema the way results name it
+	schema := schemaFile
+	if src, err := validator.ParseSource(schemaFile); err == nil {
+		schema = src.Location
 	}
 
-	jsonData, err := fileContentsNormalizer(configFile)
+	r, err := report.New(format, out, report.Options{
+		Schema:          schema,
+		OutputVersion:   outputVersion,
+		OutputStructure: outputStructure,
+		Color:           useColor,
+		ToolVersion:     Version,
+		Template:        templateText,
+	})
 	if err != nil {
-		return nil, err
+		return nil, &validator.UsageError{Err: err}
 	}
 
-	documentLoader := gojsonschema.NewBytesLoader(jsonData)
-	// XXX allow reference loader to use URLs as well as local files
-	schemaLoader := gojsonschema.NewReferenceLoader("file://" + schemaFile)
-	validated, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	return r, nil
+}
 
-	result := ValidatorResult{
-		IsValid: false,
-		Exceptions: []ExceptionDetail{},
-		Config: configFile,
-		Schema: schemaFile,
+// doValidate is the entry point into validating JSON documents. It validates
+// every config against schemaFile, jobs of them at a time, and reports the
+// results to out in the order of configs, in the --format of the command. A
+// config that cannot be read is reported with the error, and does not stop
+// the others from being validated. Configs in failed, such as patterns that
+// matched nothing, are reported with their error rather than validated.
+//
+// The returned error reflects the worst outcome and decides the exit code:
+// *validator.InvalidError when a document does not validate,
+// *validator.SchemaError or *validator.ConfigError when the schema or a
+// config could not be read.
+func doValidate(out io.Writer, schemaFile string, configs []string, failed map[string]error) error {
+	r, err := newReporter(out, schemaFile)
+	if err != nil {
+		return err
 	}
 
+	v, err := newValidator(schemaFile)
 	if err != nil {
-		result.appendExceptionWithPath(err, "a general exception occurred; probably an invalid schema; see https://github.com/xeipuuv/gojsonschema/issues/160")
-	} else {
-		if validated.Valid() {
-			result.IsValid = true
-		} else {
-			for _, desc := range validated.Errors() {
-				result.appendExceptionMessage(desc.String(), desc.Context().String())
+		for _, configFile := range configs {
+			if reportErr := r.Report(validator.FileResult{Path: configFile, Err: err}); reportErr != nil {
+				return reporterError(reportErr)
 			}
 		}
+
+		if closeErr := r.Close(); closeErr != nil {
+			return reporterError(closeErr)
+		}
+
+		return err
 	}
 
-	return json.Marshal(result)
-}
+	var errs []error
+	var paths []string
 
-// jsonStrRespValidate calls JSONDataRespValidate() and marshalls the JSON response to
-// a string returning the string to the caller.
-func jsonStrRespValidate(schemaFile string, configFile string) (jsonOutput string, err error) {
-	jsonResponse, err := validate(schemaFile, configFile)
-	if err == nil {
-		return string(jsonResponse), nil
+	for _, configFile := range configs {
+		if failed[configFile] == nil {
+			paths = append(paths, configFile)
+		}
 	}
 
-	result := ValidatorResult{
-		IsValid: false,
-		Exceptions: []ExceptionDetail{},
-		Config: configFile,
-		Schema: schemaFile,
+	// failures are reported where they stand in configs, before the
+	// outcome of the next config validated; outcomes come in the order of
+	// paths
+	next := 0
+	reportFailed := func() error {
+		for ; next < len(configs) && failed[configs[next]] != nil; next++ {
+			outcome := validator.FileResult{Path: configs[next], Err: failed[configs[next]]}
+			errs = append(errs, outcome.Err)
+
+			if err := r.Report(outcome); err != nil {
+				return reporterError(err)
+			}
+		}
+
+		return nil
 	}
 
-	result.appendException(err)
+	err = v.ValidateFiles(context.Background(), paths, jobs, func(outcome validator.FileResult) error {
+		if err := reportFailed(); err != nil {
+			return err
+		}
+		next++
 
-	errResult, err := json.Marshal(result)
+		if outcome.Err != nil {
+			errs = append(errs, outcome.Err)
+		}
+
+		for _, result := range outcome.Results {
+			errs = append(errs, result.Err())
+		}
+
+		if err := r.Report(outcome); err != nil {
+			return reporterError(err)
+		}
 
+		return nil
+	})
 	if err != nil {
-		result.appendException(err)
+		return err
 	}
 
-	return string(errResult), err
-}
+	if err := reportFailed(); err != nil {
+		return err
+	}
 
-// validate is simply a method alias which points to JSONDataRespValidate making
-// `validate` by default return a go data structure to the caller. If one wants
-// to get a JSON string returned explicitly then one must call `jsonStrRespValidate()`
-func validate(schemaFile string, configFile string) (jsonOutput []byte, err error) {
-	return JSONDataRespValidate(schemaFile, configFile)
-}
+	if err := r.Close(); err != nil {
+		return reporterError(err)
+	}
 
-// DoValidate is the entry point into validating JSON documents
-func doValidate(schemaFile string, configFile string) (err error) {
-	registerCustomFormatters()
+	return worstError(errs)
+}
 
-	if jsonstr, err := jsonStrRespValidate(schemaFile, configFile); err != nil {
-		fmt.Println(err.Error())
-	} else {
-		fmt.Println(jsonstr)
+// reporterError returns err, raised by a reporter, as the error deciding
+// the exit code: a usage error for a template of the user that fails to
+// execute, an internal error otherwise.
+func reporterError(err error) error {
+	if _, ok := err.(*validator.UsageError); ok {
+		return err
 	}
 
-	return err
+	return &internalError{err: err}
 }
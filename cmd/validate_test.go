@@ -15,16 +15,17 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/samsung-cnct/jsonsvalidator/validator"
 	"gopkg.in/yaml.v2"
 )
 
-var testYAML = "../tests.yaml"
+var testYAML = "tests.yaml"
 
 type testCase struct {
 		name       string
@@ -52,11 +53,15 @@ type Tests struct {
 func TestTablesUsingYAML(t *testing.T) {
 	var config YAMLConf
 
-	testYamlFile, _ := ioutil.ReadFile(testYAML)
-	err := yaml.Unmarshal(testYamlFile, &config)
+	testYamlFile, err := ioutil.ReadFile(testYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = yaml.Unmarshal(testYamlFile, &config)
 
 	if err != nil {
-		t.Fatalf(err.Error())
+		t.Fatal(err)
 	}
 
 	SuccessMap := map[string]bool{"success": true, "fail": false}
@@ -64,7 +69,7 @@ func TestTablesUsingYAML(t *testing.T) {
 
 	for _, thisTest := range config.Tests {
 		var testCase testCase
-		var validated ValidatorResult
+		var validated []*validator.ValidatorResult
 
 		// And the name is
 		testCase.name = thisTest.Name
@@ -87,34 +92,83 @@ func TestTablesUsingYAML(t *testing.T) {
 		// Verify schema and config file for this test run exist
 		testCase.schema, testCase.config = schema, config
 
-		// register custom formatters
-		registerCustomFormatters()
-
 		// Run validation between schema and config
-		jsondata, err := validate(schema, config);
+		validated, err = validateFile(schema, config)
 
 		if err != nil {
-			t.Error("validating failed: ", err)
+			t.Fatal("validating failed: ", err)
 		}
 
 		commonOutStr := "\n\tTest |    %-35s| %-30s\n\tConfig: `%s`\n\tSchema: `%s`.\n\tExpected: %-20v\n\tHad: %v\n"
 		commonOutErr := "\tError(s): `%+v`\n\n"
 
-		if err = json.Unmarshal(jsondata, &validated); err != nil {
-			t.Fatalf(err.Error())
+		// a config is valid when all of its documents are
+		isValid := true
+		var exceptions []validator.ExceptionDetail
+		for _, result := range validated {
+			isValid = isValid && result.IsValid
+			exceptions = append(exceptions, result.Exceptions...)
 		}
 
-		testCase.have = SuccessMapRev[validated.IsValid]
+		testCase.have = SuccessMapRev[isValid]
 
-		if validated.IsValid == SuccessMap[thisTest.Expect] {
+		if isValid == SuccessMap[thisTest.Expect] {
 			testCase.success = true
 			t.Logf(commonOutStr+"\n", testCase.name, "SUCCEEDED", testCase.config,
 				testCase.schema, testCase.expect, testCase.have)
 		} else {
 			testCase.success = false
 			t.Errorf(commonOutStr+commonOutErr, testCase.name, "FAILED!!", testCase.config,
-				testCase.schema, testCase.expect, testCase.have, validated.Exceptions)
+				testCase.schema, testCase.expect, testCase.have, exceptions)
+		}
+
+	}
+}
+
+func TestHTTPHeadersFromEnv(t *testing.T) {
+	defer os.Unsetenv(envHTTPAuthorization)
+	defer os.Unsetenv(envHTTPHeaders)
+
+	os.Setenv(envHTTPAuthorization, "Bearer secret")
+	os.Setenv(envHTTPHeaders, "X-One: 1; X-Two: 2\n")
+
+	opts, err := httpHeadersFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(opts) != 3 {
+		t.Errorf("expected 3 header options, got %d", len(opts))
+	}
+
+	os.Setenv(envHTTPHeaders, "no-colon")
+
+	if _, err := httpHeadersFromEnv(); err == nil {
+		t.Error("expected an error for a malformed header")
+	}
+}
+
+func TestRequiredFlagHasArgs(t *testing.T) {
+	// paths are not patterns: none of these is mistaken for the flag name
+	for _, arg := range []string{"schema", "schemas/schema.json", "x(.json", ".*"} {
+		if err := RequiredFlagHasArgs("schema", arg); err != nil {
+			t.Errorf("%q: %v", arg, err)
 		}
+	}
+
+	if err := RequiredFlagHasArgs("schema", ""); err == nil {
+		t.Error("expected an empty argument to be rejected")
+	}
+}
 
+// validateFile validates configFile against schemaFile with the validator
+// the command builds, returning one validation result per document of
+// configFile.
+func validateFile(schemaFile string, configFile string) ([]*validator.ValidatorResult, error) {
+	v, err := newValidator(schemaFile)
+	if err != nil {
+		return nil, err
 	}
+
+	return v.ValidateFileDocuments(context.Background(), configFile)
 }
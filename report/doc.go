@@ -0,0 +1,32 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report writes the outcomes of validating configs in the output
+// formats of the jsonsvalidator command: JSON lines, grouped human-readable
+// text, JUnit XML, SARIF, GitHub Actions annotations and GitLab Code Quality
+// reports, plus the template format, rendering each ValidatorResult through
+// a text/template given as Options.Template (the --template flag, or the
+// contents of the file named by --template-file). Each format is a Reporter,
+// picked by name with New:
+//
+//	r, err := report.New("sarif", os.Stdout, report.Options{Schema: schema})
+//	if err != nil {
+//		return err
+//	}
+//
+//	err = v.ValidateFiles(ctx, paths, jobs, r.Report)
+//	if err == nil {
+//		err = r.Close()
+//	}
+package report
@@ -0,0 +1,89 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// githubReporter writes GitHub Actions workflow commands, one ::error
+// per error, which GitHub shows as annotations on the offending lines.
+type githubReporter struct {
+	w io.Writer
+}
+
+func newGitHubReporter(w io.Writer, opts Options) (Reporter, error) {
+	return &githubReporter{w: w}, nil
+}
+
+// Report writes an annotation per error of the config, or a single one
+// when it could not be validated.
+func (r *githubReporter) Report(outcome validator.FileResult) error {
+	var buf bytes.Buffer
+
+	if outcome.Err != nil {
+		writeCommand(&buf, outcome.Path, validator.ExceptionDetail{}, errorKind(outcome.Err), outcome.Err.Error())
+	}
+
+	for _, result := range outcome.Results {
+		for _, e := range result.Exceptions {
+			writeCommand(&buf, result.Config, e, ruleID(e), e.ErrorString)
+		}
+	}
+
+	_, err := buf.WriteTo(r.w)
+
+	return err
+}
+
+// Close does nothing; every annotation is written by Report.
+func (r *githubReporter) Close() error {
+	return nil
+}
+
+// writeCommand writes the command annotating e in config: ::error, or
+// ::warning and ::notice for the warnings and notes of rules.
+func writeCommand(buf *bytes.Buffer, config string, e validator.ExceptionDetail, title string, message string) {
+	properties := []string{"file=" + escapeProperty(config)}
+	if e.Line > 0 {
+		properties = append(properties, fmt.Sprintf("line=%d", e.Line), fmt.Sprintf("col=%d", e.Column))
+	}
+	properties = append(properties, "title="+escapeProperty(title))
+
+	command := "error"
+	switch e.Severity {
+	case validator.SeverityWarning:
+		command = "warning"
+	case validator.SeverityInfo:
+		command = "notice"
+	}
+
+	fmt.Fprintf(buf, "::%s %s::%s\n", command, strings.Join(properties, ","), escapeData(message))
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
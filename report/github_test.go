@@ -0,0 +1,46 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGitHubReporter(t *testing.T) {
+	out := reportAll(t, "github", Options{})
+
+	want := "::error file=stream.yaml,line=3,col=7,title=invalid_type::name: Invalid type. Expected: string, given: integer\n" +
+		"::error file=stream.yaml,line=4,col=7,title=number_gte::port: Must be greater than or equal to 1\n" +
+		"::error file=missing.yaml,title=config-error::no such file\n"
+
+	if out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+func TestEscapeWorkflowCommands(t *testing.T) {
+	if got := escapeData("50% off\nnext"); got != "50%25 off%0Anext" {
+		t.Errorf("unexpected escaped data %q", got)
+	}
+
+	if got := escapeProperty("C:\\a,b.yaml"); got != "C%3A\\a%2Cb.yaml" {
+		t.Errorf("unexpected escaped property %q", got)
+	}
+
+	if strings.Contains(escapeProperty("a\r\nb"), "\n") {
+		t.Error("expected newlines to be escaped")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// gitlabReporter writes a GitLab Code Quality report, a JSON array with an
+// issue per error, which GitLab shows in merge requests.
+type gitlabReporter struct {
+	w            io.Writer
+	issues       []gitlabIssue
+	fingerprints fingerprints
+}
+
+// gitlabIssue is one error in a Code Quality report.
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+func newGitLabReporter(w io.Writer, opts Options) (Reporter, error) {
+	return &gitlabReporter{w: w, issues: []gitlabIssue{}}, nil
+}
+
+// Report records an issue per error of the config, or a single blocker
+// when it could not be validated.
+func (r *gitlabReporter) Report(outcome validator.FileResult) error {
+	if outcome.Err != nil {
+		kind := errorKind(outcome.Err)
+		r.add(outcome.Path, 1, gitlabIssue{
+			Description: outcome.Err.Error(),
+			CheckName:   kind,
+			Fingerprint: r.fingerprints.of(outcome.Path, nil, "", kind),
+			Severity:    "blocker",
+		})
+	}
+
+	for _, result := range outcome.Results {
+		for _, e := range result.Exceptions {
+			id := ruleID(e)
+			r.add(result.Config, e.Line, gitlabIssue{
+				Description: e.ErrorString,
+				CheckName:   id,
+				Fingerprint: r.fingerprints.of(result.Config, result, e.Pointer, id),
+				Severity:    gitlabSeverity(e),
+			})
+		}
+	}
+
+	return nil
+}
+
+// gitlabSeverity returns the severity of the issue of e: major for errors,
+// minor for warnings of rules and info for their notes.
+func gitlabSeverity(e validator.ExceptionDetail) string {
+	switch e.Severity {
+	case validator.SeverityWarning:
+		return "minor"
+	case validator.SeverityInfo:
+		return "info"
+	}
+
+	return "major"
+}
+
+// add records issue, located at line of config. Issues without a line
+// are reported on the first one.
+func (r *gitlabReporter) add(config string, line int, issue gitlabIssue) {
+	if line < 1 {
+		line = 1
+	}
+
+	issue.Location.Path = config
+	issue.Location.Lines.Begin = line
+	r.issues = append(r.issues, issue)
+}
+
+// Close writes the report.
+func (r *gitlabReporter) Close() error {
+	out, err := json.MarshalIndent(r.issues, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(r.w, "%s\n", out)
+
+	return err
+}
@@ -0,0 +1,56 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGitLabReporter(t *testing.T) {
+	out := reportAll(t, "gitlab", Options{})
+
+	var issues []gitlabIssue
+	if err := json.Unmarshal([]byte(out), &issues); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+
+	if len(issues) != 3 {
+		t.Fatalf("expected an issue per error and failure, got %d:\n%s", len(issues), out)
+	}
+
+	first := issues[0]
+	if first.CheckName != "invalid_type" || first.Severity != "major" || first.Location.Path != "stream.yaml" || first.Location.Lines.Begin != 3 {
+		t.Errorf("unexpected first issue %+v", first)
+	}
+
+	if failed := issues[2]; failed.CheckName != "config-error" || failed.Severity != "blocker" || failed.Location.Lines.Begin != 1 {
+		t.Errorf("unexpected issue of the unreadable config %+v", failed)
+	}
+
+	// fingerprints are unique, and the same on the next run
+	again := reportAll(t, "gitlab", Options{})
+	if again != out {
+		t.Errorf("expected the same report twice, got:\n%s\nthen:\n%s", out, again)
+	}
+
+	seen := map[string]bool{}
+	for _, issue := range issues {
+		if seen[issue.Fingerprint] {
+			t.Errorf("duplicate fingerprint %s", issue.Fingerprint)
+		}
+		seen[issue.Fingerprint] = true
+	}
+}
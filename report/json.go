@@ -0,0 +1,78 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// jsonReporter writes each result as one line of JSON, laid out as the
+// output version or as a standard output structure. It is the original
+// output of the command.
+type jsonReporter struct {
+	w    io.Writer
+	opts Options
+}
+
+func newJSONReporter(w io.Writer, opts Options) (Reporter, error) {
+	return &jsonReporter{w: w, opts: opts}, nil
+}
+
+// Report writes one line per document of the config, or a single line
+// holding the error when it could not be validated.
+func (r *jsonReporter) Report(outcome validator.FileResult) error {
+	if outcome.Err != nil {
+		return r.write(failure(outcome.Path, r.opts.Schema, outcome.Err))
+	}
+
+	for _, result := range outcome.Results {
+		if err := r.write(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close does nothing; every line is written by Report.
+func (r *jsonReporter) Close() error {
+	return nil
+}
+
+func (r *jsonReporter) write(result *validator.ValidatorResult) error {
+	var output interface{}
+	var err error
+
+	if r.opts.OutputStructure != "" {
+		output, err = result.Structured(r.opts.OutputStructure)
+	} else {
+		output, err = result.Output(r.opts.OutputVersion)
+	}
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.w.Write(append(line, '\n'))
+
+	return err
+}
@@ -0,0 +1,80 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+func TestJSONReporter(t *testing.T) {
+	out := reportAll(t, "json", Options{Schema: "schema.json", OutputVersion: validator.OutputV2})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a line per document and failure, got %d:\n%s", len(lines), out)
+	}
+
+	var results []validator.ValidatorResult
+	for _, line := range lines {
+		var result validator.ValidatorResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+
+		results = append(results, result)
+	}
+
+	if stream := results[2]; stream.IsValid || stream.Document != 1 || len(stream.Exceptions) != 2 {
+		t.Errorf("unexpected result of the invalid document: %+v", stream)
+	}
+
+	if failed := results[3]; failed.IsValid || failed.Schema != "schema.json" || failed.Exceptions[0].ErrorString != "no such file" {
+		t.Errorf("unexpected result of the unreadable config: %+v", failed)
+	}
+
+	if results[0].Version != validator.OutputV2 {
+		t.Errorf("expected output version %d, got %d", validator.OutputV2, results[0].Version)
+	}
+}
+
+func TestJSONReporterStructure(t *testing.T) {
+	out := reportAll(t, "json", Options{OutputStructure: validator.OutputBasic})
+
+	want := `{"valid":true}
+{"valid":true}
+{"valid":false,"errors":[{"valid":false,"keywordLocation":"/properties/name/type","absoluteKeywordLocation":"file://` + schemaPath(t) + `#/properties/name/type","instanceLocation":"/name","error":"name: Invalid type. Expected: string, given: integer"},{"valid":false,"keywordLocation":"/properties/port/minimum","absoluteKeywordLocation":"file://` + schemaPath(t) + `#/properties/port/minimum","instanceLocation":"/port","error":"port: Must be greater than or equal to 1"}]}
+{"valid":false,"errors":[{"valid":false,"keywordLocation":"","instanceLocation":"","error":"no such file"}]}
+`
+
+	if out != want {
+		t.Errorf("expected:\n%s\ngot:\n%s", want, out)
+	}
+}
+
+// schemaPath returns the path of the test schema, named relative to the
+// working directory.
+func schemaPath(t *testing.T) string {
+	path, err := filepath.Abs("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,141 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// junitReporter writes a JUnit XML report with one test case per config
+// and one failure per error, for CI systems that chart test results.
+type junitReporter struct {
+	w     io.Writer
+	suite junitTestSuite
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds the configs validated against one schema.
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is one config. It fails with one failure per error, or
+// errors when the config could not be validated.
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Error     *junitFailure  `xml:"error"`
+}
+
+// junitFailure is a failure or an error of a test case.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+func newJUnitReporter(w io.Writer, opts Options) (Reporter, error) {
+	return &junitReporter{w: w, suite: junitTestSuite{Name: opts.Schema}}, nil
+}
+
+// Report records the test case of the config.
+func (r *junitReporter) Report(outcome validator.FileResult) error {
+	test := junitTestCase{
+		Name:      outcome.Path,
+		Classname: "jsonsvalidator",
+		File:      outcome.Path,
+	}
+
+	if outcome.Err != nil {
+		test.Error = &junitFailure{
+			Message: outcome.Err.Error(),
+			Type:    errorKind(outcome.Err),
+		}
+		r.suite.Errors++
+	}
+
+	for _, result := range outcome.Results {
+		for _, e := range result.Exceptions {
+			// warnings and notes of rules do not fail the test case
+			if !e.IsError() {
+				continue
+			}
+
+			test.Failures = append(test.Failures, junitFailure{
+				Message: e.ErrorString,
+				Type:    ruleID(e),
+				Text:    junitText(result, e),
+			})
+		}
+	}
+
+	if len(test.Failures) > 0 {
+		r.suite.Failures++
+	}
+
+	r.suite.Tests++
+	r.suite.Cases = append(r.suite.Cases, test)
+
+	return nil
+}
+
+// Close writes the report.
+func (r *junitReporter) Close() error {
+	report := junitTestSuites{
+		Name:     "jsonsvalidator",
+		Tests:    r.suite.Tests,
+		Failures: r.suite.Failures,
+		Errors:   r.suite.Errors,
+		Suites:   []junitTestSuite{r.suite},
+	}
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(r.w, "%s%s\n", xml.Header, out)
+
+	return err
+}
+
+// junitText locates e in the config, followed by the lines it points at.
+func junitText(result *validator.ValidatorResult, e validator.ExceptionDetail) string {
+	location := result.Config
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", location, e.Line, e.Column)
+	}
+
+	return fmt.Sprintf("%s: %s\n%s", location, e.ErrorString, result.Snippet(e))
+}
@@ -0,0 +1,54 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestJUnitReporter(t *testing.T) {
+	out := reportAll(t, "junit", Options{Schema: "schema.json"})
+
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+
+	if report.Tests != 3 || report.Failures != 1 || report.Errors != 1 {
+		t.Errorf("expected 3 tests, 1 failure and 1 error, got %d, %d and %d", report.Tests, report.Failures, report.Errors)
+	}
+
+	if len(report.Suites) != 1 || report.Suites[0].Name != "schema.json" {
+		t.Fatalf("expected a single suite named after the schema, got %+v", report.Suites)
+	}
+
+	cases := report.Suites[0].Cases
+	if len(cases) != 3 {
+		t.Fatalf("expected a test case per config, got %d", len(cases))
+	}
+
+	if len(cases[0].Failures) != 0 || cases[0].Error != nil {
+		t.Errorf("expected the valid config to pass, got %+v", cases[0])
+	}
+
+	if failures := cases[1].Failures; len(failures) != 2 || failures[0].Type != "invalid_type" || failures[1].Type != "number_gte" {
+		t.Errorf("expected a failure per error, got %+v", failures)
+	}
+
+	if cases[2].Error == nil || cases[2].Error.Type != "config-error" {
+		t.Errorf("expected the unreadable config to error, got %+v", cases[2])
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// Reporter writes the outcomes of validating configs in one output format.
+// Report is called once per config, in order, and Close once at the end;
+// formats holding a single document, such as JUnit XML and SARIF, write
+// it on Close.
+type Reporter interface {
+	// Report writes, or records, the outcome of validating one config.
+	Report(outcome validator.FileResult) error
+
+	// Close writes whatever the format still holds. It does not close the
+	// underlying writer.
+	Close() error
+}
+
+// Options tune the reporters built by New.
+type Options struct {
+	// Schema names the schema configs are validated against. It is
+	// reported for configs that could not be validated.
+	Schema string
+
+	// OutputVersion is the JSON layout of results in the json format, see
+	// validator.ValidatorResult.Output. It defaults to validator.OutputV1.
+	OutputVersion int
+
+	// OutputStructure, when set, lays results of the json format out as
+	// one of the standard output structures of JSON Schema instead, see
+	// validator.ValidatorResult.Structured.
+	OutputStructure string
+
+	// Color turns on ANSI colours in the text format, see ColorEnabled.
+	Color bool
+
+	// ToolVersion is the version of jsonsvalidator named in SARIF logs.
+	ToolVersion string
+
+	// Template is the text/template of the template format. It is
+	// rendered for each ValidatorResult, see parseTemplate for the
+	// functions it can call.
+	Template string
+}
+
+// newReporters builds the reporter of each format.
+var newReporters = map[string]func(w io.Writer, opts Options) (Reporter, error){
+	"json":     newJSONReporter,
+	"text":     newTextReporter,
+	"junit":    newJUnitReporter,
+	"sarif":    newSARIFReporter,
+	"github":   newGitHubReporter,
+	"gitlab":   newGitLabReporter,
+	"template": newTemplateReporter,
+}
+
+// Formats returns the names of the formats known to New, sorted.
+func Formats() []string {
+	var names []string
+	for name := range newReporters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns the reporter writing format to w.
+func New(format string, w io.Writer, opts Options) (Reporter, error) {
+	newReporter, ok := newReporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q; supported formats are %v", format, Formats())
+	}
+
+	if opts.OutputVersion == 0 {
+		opts.OutputVersion = validator.OutputV1
+	}
+
+	if opts.OutputVersion < validator.OutputV1 || opts.OutputVersion > validator.LatestOutput {
+		return nil, fmt.Errorf("unsupported output version %d; supported versions are %d to %d",
+			opts.OutputVersion, validator.OutputV1, validator.LatestOutput)
+	}
+
+	if opts.OutputStructure != "" && !contains(validator.OutputStructures, opts.OutputStructure) {
+		return nil, fmt.Errorf("unsupported output structure %q; supported structures are %v",
+			opts.OutputStructure, validator.OutputStructures)
+	}
+
+	return newReporter(w, opts)
+}
+
+// ColorEnabled tells whether output to w should be coloured: w must be a
+// terminal, and the NO_COLOR environment variable (https://no-color.org)
+// must be unset or empty.
+func ColorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failure returns the result reported for a config that could not be
+// validated because of err.
+func failure(config string, schema string, err error) *validator.ValidatorResult {
+	result := validator.NewResult(config, schema)
+	result.AppendException(err)
+
+	return result
+}
+
+// errorKind names the reason a config could not be validated.
+func errorKind(err error) string {
+	switch err.(type) {
+	case *validator.SchemaError:
+		return "schema-error"
+	case *validator.UsageError:
+		return "usage-error"
+	}
+
+	return "config-error"
+}
+
+// ruleID names the schema check or policy rule behind exception e.
+func ruleID(e validator.ExceptionDetail) string {
+	if e.Rule != "" {
+		return e.Rule
+	}
+
+	if e.Type == "" {
+		return "invalid"
+	}
+
+	return e.Type
+}
+
+// fingerprints derives identifiers of errors that stay the same from one
+// run to the next, so CI systems can tell new errors from known ones.
+type fingerprints struct {
+	seen map[string]int
+}
+
+// of returns the fingerprint of the error of type kind at pointer in the
+// document of result, or in config when result is nil. It hashes the
+// config, pointer and type, plus the document index in YAML streams. Errors
+// sharing all of them, such as two missing properties of one object, are
+// told apart by their order.
+func (f *fingerprints) of(config string, result *validator.ValidatorResult, pointer string, kind string) string {
+	key := config + "\x00" + pointer + "\x00" + kind
+	if result != nil && result.Documents > 1 {
+		key += fmt.Sprintf("\x00%d", result.Document)
+	}
+
+	if f.seen == nil {
+		f.seen = map[string]int{}
+	}
+
+	if n := f.seen[key]; n > 0 {
+		f.seen[key]++
+		key += fmt.Sprintf("\x00#%d", n)
+	} else {
+		f.seen[key] = 1
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
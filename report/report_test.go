@@ -0,0 +1,195 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+const testSchema = `{
+	"properties": {
+		"name": {"type": "string"},
+		"port": {"type": "integer", "minimum": 1}
+	}
+}`
+
+// testOutcomes validates a valid config, a stream whose second document is
+// invalid, and reports a config that could not be read.
+func testOutcomes(t *testing.T) []validator.FileResult {
+	v, err := validator.New(validator.WithSchemaBytes("schema.json", []byte(testSchema)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var outcomes []validator.FileResult
+	for _, config := range []struct{ path, data string }{
+		{"valid.yaml", "name: web\nport: 80\n"},
+		{"stream.yaml", "name: web\n---\nname: 7\nport: 0\n"},
+	} {
+		results, err := v.ValidateDocuments(context.Background(), config.path, []byte(config.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		outcomes = append(outcomes, validator.FileResult{Path: config.path, Results: results})
+	}
+
+	return append(outcomes, validator.FileResult{
+		Path: "missing.yaml",
+		Err:  &validator.ConfigError{Config: "missing.yaml", Err: errors.New("no such file")},
+	})
+}
+
+// reportAll reports every test outcome in format and returns the output.
+func reportAll(t *testing.T, format string, opts Options) string {
+	var out bytes.Buffer
+
+	r, err := New(format, &out, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, outcome := range testOutcomes(t) {
+		if err := r.Report(outcome); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return out.String()
+}
+
+func TestNew(t *testing.T) {
+	if formats := Formats(); !reflect.DeepEqual(formats, []string{"github", "gitlab", "json", "junit", "sarif", "template", "text"}) {
+		t.Errorf("unexpected formats %v", formats)
+	}
+
+	if _, err := New("yaml", &bytes.Buffer{}, Options{}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+
+	if _, err := New("json", &bytes.Buffer{}, Options{OutputVersion: validator.LatestOutput + 1}); err == nil {
+		t.Error("expected an error for an unsupported output version")
+	}
+
+	if _, err := New("json", &bytes.Buffer{}, Options{OutputStructure: "compact"}); err == nil {
+		t.Error("expected an error for an unsupported output structure")
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	if ColorEnabled(&bytes.Buffer{}) {
+		t.Error("expected no colours for a buffer")
+	}
+
+	defer os.Unsetenv("NO_COLOR")
+	os.Setenv("NO_COLOR", "1")
+
+	if ColorEnabled(os.Stdout) {
+		t.Error("expected NO_COLOR to turn colours off")
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	var f fingerprints
+
+	required := f.of("a.yaml", nil, "", "required")
+	if required != (&fingerprints{}).of("a.yaml", nil, "", "required") {
+		t.Error("expected fingerprints to be stable")
+	}
+
+	if f.of("a.yaml", nil, "", "required") == required {
+		t.Error("expected repeated errors to get their own fingerprint")
+	}
+
+	for _, other := range []string{
+		f.of("b.yaml", nil, "", "required"),
+		f.of("a.yaml", nil, "/name", "required"),
+		f.of("a.yaml", nil, "", "enum"),
+		f.of("a.yaml", &validator.ValidatorResult{Document: 1, Documents: 2}, "", "required"),
+	} {
+		if other == required {
+			t.Error("expected the file, pointer, type and document to change the fingerprint")
+		}
+	}
+}
+
+func TestRuleSeverities(t *testing.T) {
+	rules, err := validator.ParseRules("rules.yaml", []byte(`
+rules:
+  - {id: min-port, expression: "@.port >= 1024"}
+  - {id: named, severity: warning, expression: "@.name != null"}
+  - {id: short, severity: info, expression: "len(@.name) < 8"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := validator.New(validator.WithSchemaBytes("schema.json", []byte(testSchema)), validator.WithRules(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := v.ValidateDocuments(context.Background(), "rules.yaml", []byte("port: 80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"github", []string{"::error file=rules.yaml,line=1,col=1,title=min-port::", "::warning file=rules.yaml,line=1,col=1,title=named::"}},
+		{"gitlab", []string{`"check_name": "min-port"`, `"severity": "major"`, `"check_name": "named"`, `"severity": "minor"`}},
+		{"sarif", []string{`"text": "Policy rule \"named\" is not satisfied"`, `"level": "warning"`, `"level": "error"`}},
+		{"junit", []string{`failures="1"`, `type="min-port"`}},
+		{"text", []string{"[min-port]", "[named warning]", "(1 error), 1 warning"}},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+
+		r, err := New(test.format, &out, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Report(validator.FileResult{Path: "rules.yaml", Results: results}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range test.want {
+			if !bytes.Contains(out.Bytes(), []byte(want)) {
+				t.Errorf("%s: expected %q in:\n%s", test.format, want, out.String())
+			}
+		}
+
+		if test.format == "junit" && bytes.Contains(out.Bytes(), []byte(`type="named"`)) {
+			t.Errorf("junit: expected warnings not to fail the test case:\n%s", out.String())
+		}
+	}
+}
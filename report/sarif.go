@@ -0,0 +1,248 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// Identity of the tool in SARIF logs.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "jsonsvalidator"
+	toolURI      = "https://github.com/samsung-cnct/jsonsvalidator"
+)
+
+// sarifReporter writes a SARIF 2.1.0 log with one result per error, for
+// code scanning tools that annotate the offending lines.
+type sarifReporter struct {
+	w            io.Writer
+	run          sarifRun
+	ruleIDs      map[string]int
+	fingerprints fingerprints
+}
+
+// sarifLog is the root object of a SARIF log.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is the single run of jsonsvalidator in the log.
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+// sarifTool describes jsonsvalidator and the rules its results refer to.
+type sarifTool struct {
+	Driver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+// sarifRule is a kind of error, named after its gojsonschema type.
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+// sarifResult is one error, located in its config.
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+
+	// PartialFingerprints identify the error across runs.
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+// sarifRegion spans the offending node. SARIF end columns are exclusive,
+// as are those of validator.ExceptionDetail.
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func newSARIFReporter(w io.Writer, opts Options) (Reporter, error) {
+	r := &sarifReporter{
+		w:       w,
+		ruleIDs: map[string]int{},
+		run: sarifRun{
+			// columns count characters, not bytes
+			ColumnKind: "unicodeCodePoints",
+			Results:    []sarifResult{},
+		},
+	}
+
+	driver := &r.run.Tool.Driver
+	driver.Name, driver.Version, driver.InformationURI = toolName, opts.ToolVersion, toolURI
+	driver.Rules = []sarifRule{}
+
+	return r, nil
+}
+
+// Report records a result per error of the config, or a single result
+// when it could not be validated.
+func (r *sarifReporter) Report(outcome validator.FileResult) error {
+	if outcome.Err != nil {
+		kind := errorKind(outcome.Err)
+		r.add(kind, "The config could not be validated", sarifResult{
+			Message:             sarifMessage{Text: outcome.Err.Error()},
+			Locations:           []sarifLocation{location(outcome.Path, validator.ExceptionDetail{})},
+			PartialFingerprints: sarifFingerprint(r.fingerprints.of(outcome.Path, nil, "", kind)),
+		})
+
+		return nil
+	}
+
+	for _, result := range outcome.Results {
+		for _, e := range result.Exceptions {
+			properties := map[string]interface{}{"pointer": e.Pointer}
+			if e.KeywordLocation != "" {
+				properties["keywordLocation"] = e.KeywordLocation
+			}
+
+			id := ruleID(e)
+			description := fmt.Sprintf("Schema keyword %q is not satisfied", id)
+			if e.Rule != "" {
+				description = fmt.Sprintf("Policy rule %q is not satisfied", id)
+			}
+
+			r.add(id, description, sarifResult{
+				Level:               sarifLevels[e.Severity],
+				Message:             sarifMessage{Text: e.ErrorString},
+				Locations:           []sarifLocation{location(result.Config, e)},
+				Properties:          properties,
+				PartialFingerprints: sarifFingerprint(r.fingerprints.of(result.Config, result, e.Pointer, id)),
+			})
+		}
+	}
+
+	return nil
+}
+
+// sarifLevels are the levels of the severities of rules.
+var sarifLevels = map[string]string{
+	validator.SeverityWarning: "warning",
+	validator.SeverityInfo:    "note",
+}
+
+// add records result as breaking rule id, declaring the rule the first
+// time it is broken. Results are errors unless given another level.
+func (r *sarifReporter) add(id string, description string, result sarifResult) {
+	index, ok := r.ruleIDs[id]
+	if !ok {
+		index = len(r.run.Tool.Driver.Rules)
+		r.ruleIDs[id] = index
+		r.run.Tool.Driver.Rules = append(r.run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: description},
+		})
+	}
+
+	result.RuleID, result.RuleIndex = id, index
+	if result.Level == "" {
+		result.Level = "error"
+	}
+	r.run.Results = append(r.run.Results, result)
+}
+
+// Close writes the log.
+func (r *sarifReporter) Close() error {
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{r.run},
+	}
+
+	out, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(r.w, "%s\n", out)
+
+	return err
+}
+
+// sarifFingerprint returns the partial fingerprints of an error.
+func sarifFingerprint(fingerprint string) map[string]string {
+	return map[string]string{toolName + "/v1": fingerprint}
+}
+
+// location returns the physical location of e in config, without a region
+// when e has no position.
+func location(config string, e validator.ExceptionDetail) sarifLocation {
+	var l sarifLocation
+	l.PhysicalLocation.ArtifactLocation.URI = artifactURI(config)
+
+	if e.Line > 0 {
+		l.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   e.Line,
+			StartColumn: e.Column,
+			EndLine:     e.EndLine,
+			EndColumn:   e.EndColumn,
+		}
+	}
+
+	return l
+}
+
+// artifactURI turns a config path into a URI reference: relative paths
+// stay relative to the working directory, absolute ones become file URIs.
+func artifactURI(path string) string {
+	u := url.URL{Path: filepath.ToSlash(path)}
+	if filepath.IsAbs(path) {
+		u.Scheme = "file"
+		if !strings.HasPrefix(u.Path, "/") {
+			// Windows drive letters
+			u.Path = "/" + u.Path
+		}
+	}
+
+	return u.String()
+}
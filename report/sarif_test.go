@@ -0,0 +1,79 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+)
+
+func TestSARIFReporter(t *testing.T) {
+	out := reportAll(t, "sarif", Options{ToolVersion: "1.2.3"})
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("expected a single SARIF 2.1.0 run, got version %q and %d runs", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("expected the tool version, got %q", run.Tool.Driver.Version)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("expected a result per error and failure, got %d", len(run.Results))
+	}
+
+	first := run.Results[0]
+	if first.RuleID != "invalid_type" || run.Tool.Driver.Rules[first.RuleIndex].ID != first.RuleID {
+		t.Errorf("unexpected rule of the first result: %+v", first)
+	}
+
+	location := first.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "stream.yaml" {
+		t.Errorf("expected the config URI, got %q", location.ArtifactLocation.URI)
+	}
+
+	region := sarifRegion{StartLine: 3, StartColumn: 7, EndLine: 3, EndColumn: 8}
+	if location.Region == nil || *location.Region != region {
+		t.Errorf("expected region %+v, got %+v", region, location.Region)
+	}
+
+	if failed := run.Results[2]; failed.RuleID != "config-error" || failed.Locations[0].PhysicalLocation.Region != nil {
+		t.Errorf("unexpected result of the unreadable config: %+v", failed)
+	}
+}
+
+func TestArtifactURI(t *testing.T) {
+	tests := map[string]string{
+		"configs/a.yaml":  "configs/a.yaml",
+		"with space.yaml": "with%20space.yaml",
+	}
+
+	if runtime.GOOS != "windows" {
+		tests["/etc/configs/a.yaml"] = "file:///etc/configs/a.yaml"
+	}
+
+	for path, want := range tests {
+		if uri := artifactURI(path); uri != want {
+			t.Errorf("%s: expected %q, got %q", path, want, uri)
+		}
+	}
+}
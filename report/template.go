@@ -0,0 +1,150 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// colors are the styles known to the color template function.
+var colors = map[string]string{
+	"bold":   ansiBold,
+	"dim":    ansiDim,
+	"red":    ansiRed,
+	"green":  ansiGreen,
+	"yellow": "\x1b[33m",
+	"blue":   "\x1b[34m",
+}
+
+// templateReporter renders each result through a user-supplied
+// text/template, see Options.Template.
+type templateReporter struct {
+	w    io.Writer
+	tmpl *template.Template
+	opts Options
+}
+
+// parseTemplate parses the template of the template format with its
+// functions:
+//
+//	json   encodes a value as JSON, e.g. {{json .Exceptions}}
+//	color  styles text when colours are on, e.g. {{color "red" .Config}};
+//	       styles are bold, dim, red, green, yellow and blue
+//	rel    makes an absolute path relative to the working directory
+func parseTemplate(text string, opts Options) (*template.Template, error) {
+	if text == "" {
+		return nil, fmt.Errorf("the template format needs a template")
+	}
+
+	funcs := template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			out, err := json.Marshal(v)
+			return string(out), err
+		},
+		"color": func(style string, s interface{}) (string, error) {
+			code, ok := colors[style]
+			if !ok {
+				return "", fmt.Errorf("unknown color %q", style)
+			}
+
+			if !opts.Color {
+				return fmt.Sprint(s), nil
+			}
+
+			return code + fmt.Sprint(s) + ansiReset, nil
+		},
+		"rel": relativePath,
+	}
+
+	return template.New("template").Funcs(funcs).Parse(text)
+}
+
+func newTemplateReporter(w io.Writer, opts Options) (Reporter, error) {
+	tmpl, err := parseTemplate(opts.Template, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templateReporter{w: w, tmpl: tmpl, opts: opts}, nil
+}
+
+// Report renders the template once per document of the config, or once
+// for the failure when it could not be validated.
+func (r *templateReporter) Report(outcome validator.FileResult) error {
+	if outcome.Err != nil {
+		return r.render(failure(outcome.Path, r.opts.Schema, outcome.Err))
+	}
+
+	for _, result := range outcome.Results {
+		if err := r.render(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close does nothing; every result is rendered by Report.
+func (r *templateReporter) Close() error {
+	return nil
+}
+
+// render executes the template for result, ending the output with a
+// newline when the template does not. A template failing to execute, such
+// as one naming a field results lack, is a *validator.UsageError like one
+// failing to parse.
+func (r *templateReporter) render(result *validator.ValidatorResult) error {
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, result); err != nil {
+		return &validator.UsageError{Err: err}
+	}
+
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	_, err := buf.WriteTo(r.w)
+
+	return err
+}
+
+// relativePath returns path relative to the working directory when it is
+// an absolute path below it, and path unchanged otherwise.
+func relativePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return rel
+}
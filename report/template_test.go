@@ -0,0 +1,106 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+func TestTemplateReporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		color    bool
+		want     string
+	}{
+		{
+			"summary",
+			`{{.Config}}: {{len .Exceptions}} errors`,
+			false,
+			"valid.yaml: 0 errors\nstream.yaml: 0 errors\nstream.yaml: 2 errors\nmissing.yaml: 1 errors\n",
+		},
+		{
+			"exceptions",
+			"{{range .Exceptions}}{{$.Config}}:{{.Line}} {{.Type}} {{json .Value}}\n{{end}}",
+			false,
+			"stream.yaml:3 invalid_type 7\nstream.yaml:4 number_gte 0\nmissing.yaml:0  null\n",
+		},
+		{
+			"colors",
+			`{{if not .IsValid}}{{color "red" .Config}}{{end}}`,
+			true,
+			ansiRed + "stream.yaml" + ansiReset + "\n" + ansiRed + "missing.yaml" + ansiReset + "\n",
+		},
+		{
+			"colors off",
+			`{{if not .IsValid}}{{color "red" .Config}}{{end}}`,
+			false,
+			"stream.yaml\nmissing.yaml\n",
+		},
+	}
+
+	for _, test := range tests {
+		out := reportAll(t, "template", Options{Template: test.template, Color: test.color})
+		if out != test.want {
+			t.Errorf("%s: expected:\n%q\ngot:\n%q", test.name, test.want, out)
+		}
+	}
+
+	for _, template := range []string{"", "{{.Config", `{{color "pink" .Config}}`} {
+		r, err := New("template", &bytes.Buffer{}, Options{Template: template})
+		if err == nil {
+			err = r.Report(testOutcomes(t)[0])
+		}
+
+		if err == nil {
+			t.Errorf("expected template %q to fail", template)
+		}
+	}
+
+	// templates failing to execute are the user's, like those failing to parse
+	r, err := New("template", &bytes.Buffer{}, Options{Template: "{{.Nope}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Report(testOutcomes(t)[0])
+	if _, ok := err.(*validator.UsageError); !ok {
+		t.Errorf("expected a *validator.UsageError for a failing template, got %T: %v", err, err)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		filepath.Join(cwd, "configs", "a.yaml"): filepath.Join("configs", "a.yaml"),
+		"configs/a.yaml":                        "configs/a.yaml",
+		filepath.Dir(cwd):                       filepath.Dir(cwd),
+	}
+
+	for path, want := range tests {
+		if got := relativePath(path); got != want {
+			t.Errorf("%s: expected %q, got %q", path, want, got)
+		}
+	}
+}
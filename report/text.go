@@ -0,0 +1,182 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/samsung-cnct/jsonsvalidator/validator"
+)
+
+// ANSI escape sequences of the text format.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+)
+
+// textReporter writes the outcomes for people: one heading per config,
+// followed by its errors and the source lines they point at, and a summary
+// on Close.
+type textReporter struct {
+	w     io.Writer
+	color bool
+
+	configs, valid, invalid, failed, errors, warnings int
+}
+
+func newTextReporter(w io.Writer, opts Options) (Reporter, error) {
+	return &textReporter{w: w, color: opts.Color}, nil
+}
+
+// Report writes the heading of the config and its errors.
+func (r *textReporter) Report(outcome validator.FileResult) error {
+	var buf bytes.Buffer
+	r.configs++
+
+	switch {
+	case outcome.Err != nil:
+		r.failed++
+		fmt.Fprintf(&buf, "%s %s\n", r.paint(ansiBold+ansiRed, "ERROR"), outcome.Path)
+		fmt.Fprintf(&buf, "  %s\n", outcome.Err)
+
+	case isValid(outcome.Results):
+		r.valid++
+		fmt.Fprintf(&buf, "%s   %s\n", r.paint(ansiGreen, "ok"), outcome.Path)
+		r.writeResults(&buf, outcome.Results)
+
+	default:
+		r.invalid++
+		fmt.Fprintf(&buf, "%s  %s\n", r.paint(ansiBold+ansiRed, "FAIL"), outcome.Path)
+		r.writeResults(&buf, outcome.Results)
+	}
+
+	_, err := io.WriteString(r.w, buf.String())
+
+	return err
+}
+
+// writeResults writes the exceptions of every document of a config, the
+// warnings of valid ones included.
+func (r *textReporter) writeResults(buf *bytes.Buffer, results []*validator.ValidatorResult) {
+	for _, result := range results {
+		if len(result.Exceptions) == 0 {
+			continue
+		}
+
+		if result.Documents > 1 {
+			fmt.Fprintf(buf, "  %s\n", r.paint(ansiBold, fmt.Sprintf("document %d of %d, line %d",
+				result.Document+1, result.Documents, result.StartLine)))
+		}
+
+		for _, e := range result.Exceptions {
+			if e.IsError() {
+				r.errors++
+			} else {
+				r.warnings++
+			}
+			r.writeException(buf, result, e)
+		}
+	}
+}
+
+// writeException writes e, prefixed by its position, and the lines it
+// points at.
+func (r *textReporter) writeException(buf *bytes.Buffer, result *validator.ValidatorResult, e validator.ExceptionDetail) {
+	position := ""
+	if e.Line > 0 {
+		position = r.paint(ansiDim, fmt.Sprintf("%d:%d", e.Line, e.Column)) + "  "
+	}
+
+	tag := ruleID(e)
+	if !e.IsError() {
+		tag += " " + e.Severity
+	}
+
+	fmt.Fprintf(buf, "  %s%s  %s\n", position, e.ErrorString, r.paint(ansiDim, "["+tag+"]"))
+
+	snippet := strings.TrimSuffix(result.Snippet(e), "\n")
+	if snippet == "" {
+		return
+	}
+
+	// Snippet alternates source lines and caret lines
+	for i, line := range strings.Split(snippet, "\n") {
+		if i%2 == 1 {
+			if bar := strings.Index(line, "| "); bar >= 0 {
+				line = line[:bar+2] + r.paint(ansiRed, line[bar+2:])
+			}
+		}
+
+		fmt.Fprintf(buf, "    %s\n", line)
+	}
+}
+
+// Close writes the summary of every config reported.
+func (r *textReporter) Close() error {
+	summary := fmt.Sprintf("%d %s: %d valid, %d invalid (%d %s)",
+		r.configs, plural(r.configs, "config", "configs"), r.valid, r.invalid,
+		r.errors, plural(r.errors, "error", "errors"))
+
+	if r.warnings > 0 {
+		summary += fmt.Sprintf(", %d %s", r.warnings, plural(r.warnings, "warning", "warnings"))
+	}
+
+	if r.failed > 0 {
+		summary += fmt.Sprintf(", %d not validated", r.failed)
+	}
+
+	color := ansiGreen
+	if r.invalid > 0 || r.failed > 0 {
+		color = ansiRed
+	}
+
+	_, err := fmt.Fprintf(r.w, "\n%s\n", r.paint(ansiBold+color, summary))
+
+	return err
+}
+
+// paint wraps s in the ANSI style when colours are on.
+func (r *textReporter) paint(style string, s string) string {
+	if !r.color {
+		return s
+	}
+
+	return style + s + ansiReset
+}
+
+// isValid tells whether every document of a config validated.
+func isValid(results []*validator.ValidatorResult) bool {
+	for _, result := range results {
+		if !result.IsValid {
+			return false
+		}
+	}
+
+	return true
+}
+
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return one
+	}
+
+	return many
+}
@@ -0,0 +1,47 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextReporter(t *testing.T) {
+	out := reportAll(t, "text", Options{})
+
+	for _, want := range []string{
+		"ok   valid.yaml\n",
+		"FAIL  stream.yaml\n  document 2 of 2, line 2\n",
+		"  3:7  name: Invalid type. Expected: string, given: integer  [invalid_type]\n" +
+			"    3 | name: 7\n" +
+			"      |       ^\n",
+		"ERROR missing.yaml\n  no such file\n",
+		"\n3 configs: 1 valid, 1 invalid (2 errors), 1 not validated\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the text output to hold %q, got:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no colours, got:\n%s", out)
+	}
+
+	colored := reportAll(t, "text", Options{Color: true})
+	if !strings.Contains(colored, ansiBold+ansiRed+"FAIL"+ansiReset) {
+		t.Errorf("expected a red FAIL, got:\n%s", colored)
+	}
+}
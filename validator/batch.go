@@ -0,0 +1,100 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"sync"
+)
+
+// FileResult is the outcome of validating one config file with
+// ValidateFiles: either the results of its documents or the error that
+// kept it from being validated.
+type FileResult struct {
+	// Path is the resolved location of the config, see Source.Location.
+	Path    string
+	Results []*ValidatorResult
+	Err     error
+}
+
+// ValidateFiles validates each document of the configs at paths, up to jobs
+// configs at a time, and hands their outcomes to report in the order of
+// paths, whatever order they complete in. A jobs value below 1 validates
+// one config at a time.
+//
+// A config that cannot be read is reported through FileResult.Err and does
+// not stop the others. ValidateFiles stops early when report returns an
+// error, returning that error, or when ctx is done, returning ctx.Err().
+func (v *Validator) ValidateFiles(ctx context.Context, paths []string, jobs int, report func(FileResult) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// each config gets its own slot, filled by whichever worker validates it
+	slots := make([]chan FileResult, len(paths))
+	for i := range slots {
+		slots[i] = make(chan FileResult, 1)
+	}
+
+	next := make(chan int)
+
+	var workers sync.WaitGroup
+	for w := 0; w < jobs && w < len(paths); w++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+
+			for i := range next {
+				results, err := v.ValidateFileDocuments(ctx, paths[i])
+				slots[i] <- FileResult{Path: resolvedLocation(paths[i]), Results: results, Err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(next)
+
+		for i := range paths {
+			select {
+			case next <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// on an early return, stop handing out configs before waiting
+	defer func() {
+		cancel()
+		workers.Wait()
+	}()
+
+	for i := range paths {
+		select {
+		case outcome := <-slots[i]:
+			if err := report(outcome); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
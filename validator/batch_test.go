@@ -0,0 +1,144 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateFiles(t *testing.T) {
+	files := map[string]string{}
+	var paths []string
+
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("config-%02d.yaml", i)
+		files[name] = fmt.Sprintf("name: config %d\ncount: %d\n", i, i%3)
+		paths = append(paths, name)
+	}
+
+	dir := writeSchemas(t, files)
+	defer os.RemoveAll(dir)
+
+	for i := range paths {
+		paths[i] = filepath.Join(dir, paths[i])
+	}
+	paths = append(paths, filepath.Join(dir, "missing.yaml"))
+
+	v := newTestValidator(t)
+
+	for _, jobs := range []int{0, 1, 8} {
+		var got []FileResult
+
+		err := v.ValidateFiles(context.Background(), paths, jobs, func(outcome FileResult) error {
+			got = append(got, outcome)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != len(paths) {
+			t.Fatalf("jobs %d: expected %d outcomes, got %d", jobs, len(paths), len(got))
+		}
+
+		for i, outcome := range got {
+			if outcome.Path != paths[i] {
+				t.Errorf("jobs %d: expected %s at %d, got %s", jobs, paths[i], i, outcome.Path)
+				continue
+			}
+
+			if i == len(paths)-1 {
+				if _, ok := outcome.Err.(*ConfigError); !ok {
+					t.Errorf("jobs %d: expected a *ConfigError for the missing config, got %v", jobs, outcome.Err)
+				}
+				continue
+			}
+
+			if valid := i%3 != 0; outcome.Err != nil || outcome.Results[0].IsValid != valid {
+				t.Errorf("jobs %d: expected %s to be valid=%v, got %+v", jobs, outcome.Path, valid, outcome)
+			}
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+
+	err := v.ValidateFiles(context.Background(), paths, 4, func(FileResult) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected ValidateFiles to stop on the first report error, got %v after %d calls", err, calls)
+	}
+}
+
+// BenchmarkValidateFiles validates thousands of full Kraken configs, one at a
+// time and in parallel.
+func BenchmarkValidateFiles(b *testing.B) {
+	const configs = 2000
+
+	kraken, err := ioutil.ReadFile(filepath.Join("..", "cmd", "test_configs", "kraken_full_valid.yaml"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	files := map[string]string{}
+	var paths []string
+
+	for i := 0; i < configs; i++ {
+		name := fmt.Sprintf("kraken-%04d.yaml", i)
+		files[name] = string(kraken)
+		paths = append(paths, name)
+	}
+
+	dir := writeSchemas(b, files)
+	defer os.RemoveAll(dir)
+
+	for i := range paths {
+		paths[i] = filepath.Join(dir, paths[i])
+	}
+
+	v, err := New(WithSchemaFile(krakenSchema(b)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, jobs := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			b.SetBytes(int64(len(kraken) * configs))
+
+			for i := 0; i < b.N; i++ {
+				err := v.ValidateFiles(context.Background(), paths, jobs, func(outcome FileResult) error {
+					if outcome.Err != nil {
+						return outcome.Err
+					}
+					if !outcome.Results[0].IsValid {
+						return fmt.Errorf("%s is not valid: %+v", outcome.Path, outcome.Results[0].Exceptions)
+					}
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
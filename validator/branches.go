@@ -0,0 +1,180 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/xeipuuv/gojsonreference"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// branchErrors returns the errors of each branch of the anyOf or oneOf
+// keyword of owner that the instance at pointer fails. gojsonschema only
+// keeps the errors of the branch it deems closest, merged with the others,
+// so each branch is validated again on its own.
+func (v *Validator) branchErrors(ctx context.Context, name string, document interface{}, positions *positionIndex, owner schemaPath, keyword string, pointer string) []ExceptionDetail {
+	branches, _ := owner.node[keyword].([]interface{})
+	value, _ := resolvePointer(document, pointer)
+
+	var causes []ExceptionDetail
+
+	for i, branch := range branches {
+		node, ok := branch.(map[string]interface{})
+		if !ok || ctx.Err() != nil {
+			continue
+		}
+
+		token := strconv.Itoa(i)
+		from := schemaPath{
+			at:       location{document: owner.at.document, pointer: appendPointer(appendPointer(owner.at.pointer, keyword), token)},
+			keywords: appendPointer(appendPointer(owner.keywords, keyword), token),
+			node:     node,
+		}
+
+		schema, err := v.subschemas.compile(from.at.String(), v.bundle, node)
+		if err != nil {
+			continue
+		}
+
+		validated, err := schema.Validate(&documentLoader{document: value})
+		if err != nil {
+			continue
+		}
+
+		var errs []ExceptionDetail
+		for _, desc := range validated.Errors() {
+			errs = append(errs, v.exception(ctx, name, document, positions, from, pointer, rebaseError(desc, pointer)))
+		}
+
+		if v.bundle.checked[from.at] {
+			for _, failure := range v.newFormatRun(ctx, document).evaluate(from, pointer, value) {
+				errs = append(errs, v.formatException(ctx, name, document, positions, failure))
+			}
+		}
+
+		errs = withoutMerged(errs)
+		sortExceptions(errs)
+		causes = append(causes, errs...)
+	}
+
+	return causes
+}
+
+// rebasedError is an error gojsonschema raised validating a subschema
+// against the instance at base on its own, told as if it had been raised
+// validating the whole document: its field, context and description name
+// the instance by its path from the root.
+type rebasedError struct {
+	gojsonschema.ResultError
+
+	base        string
+	field       string
+	details     gojsonschema.ErrorDetails
+	description string
+}
+
+// rebaseError returns desc, raised on the instance at base or below it, with
+// paths from the root of the document.
+func rebaseError(desc gojsonschema.ResultError, base string) gojsonschema.ResultError {
+	if base == "" {
+		return desc
+	}
+
+	context := instanceContext(base + contextPointer(desc))
+
+	details := gojsonschema.ErrorDetails{}
+	for key, value := range desc.Details() {
+		details[key] = value
+	}
+
+	field := failureField(context)
+	if property, ok := details["property"].(string); ok {
+		field = property
+	}
+	details["field"], details["context"] = field, context
+
+	description := desc.Description()
+	if desc.Type() == "enum" {
+		// the only message of gojsonschema naming the field
+		description, _ = renderMessage("enum", gojsonschema.Locale.Enum(), details)
+	}
+
+	return &rebasedError{ResultError: desc, base: base, field: field, details: details, description: description}
+}
+
+// Field returns the field of the instance, from the root of the document.
+func (e *rebasedError) Field() string {
+	return e.field
+}
+
+// Details returns the details of the error, with the field and context
+// from the root of the document.
+func (e *rebasedError) Details() gojsonschema.ErrorDetails {
+	return e.details
+}
+
+// Description returns the description of the error.
+func (e *rebasedError) Description() string {
+	return e.description
+}
+
+// String returns the error as gojsonschema formats it, "field: description".
+func (e *rebasedError) String() string {
+	return e.field + ": " + e.description
+}
+
+// branchURI is the internal URI of the subschemas compiled on their own.
+const branchURI = refScheme + "://branch"
+
+// branchLoader is the gojsonschema.JSONLoader of a subschema of a bundle
+// compiled on its own, and the gojsonschema.JSONLoaderFactory serving it
+// along with the bundle: the $refs of the subschema, rewritten to internal
+// URIs, are served by the bundle.
+type branchLoader struct {
+	bundle   *schemaBundle
+	document interface{}
+}
+
+// JsonSource returns the URI of the subschema.
+func (l *branchLoader) JsonSource() interface{} {
+	return branchURI
+}
+
+// LoadJSON returns the subschema.
+func (l *branchLoader) LoadJSON() (interface{}, error) {
+	return l.document, nil
+}
+
+// JsonReference returns the URI of the subschema as a reference.
+func (l *branchLoader) JsonReference() (gojsonreference.JsonReference, error) {
+	return gojsonreference.NewJsonReference(branchURI)
+}
+
+// LoaderFactory returns l.
+func (l *branchLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return l
+}
+
+// New returns l for the subschema, and the loader of the bundle for
+// any other uri. It implements gojsonschema.JSONLoaderFactory.
+func (l *branchLoader) New(uri string) gojsonschema.JSONLoader {
+	if uri == branchURI {
+		return l
+	}
+
+	return l.bundle.New(uri)
+}
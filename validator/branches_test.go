@@ -0,0 +1,166 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var branchSchema = []byte(`{
+	"definitions": {
+		"port": {"type": "integer", "minimum": 1},
+		"named": {"type": "string", "pattern": "^[a-z]+$"}
+	},
+	"properties": {
+		"services": {
+			"items": {
+				"properties": {
+					"port": {"anyOf": [{"$ref": "#/definitions/port"}, {"$ref": "#/definitions/named"}]},
+					"kind": {"oneOf": [{"enum": ["a"]}, {"enum": ["b"]}, {"type": "string"}]},
+					"mode": {"anyOf": [{"type": "integer"}, {"anyOf": [{"enum": ["on"]}, {"enum": ["off"]}]}]}
+				}
+			}
+		}
+	}
+}`)
+
+func TestBranchErrors(t *testing.T) {
+	v, err := New(WithSchemaBytes("branches.json", branchSchema), WithCauses())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := "services:\n- port: 80\n- port: Web\n  kind: a\n  mode: auto\n"
+
+	result, err := v.ValidateBytes(context.Background(), "branches.yaml", []byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	causes := map[string][]string{}
+
+	var collect func(exceptions []ExceptionDetail)
+	collect = func(exceptions []ExceptionDetail) {
+		for _, e := range exceptions {
+			if len(e.Causes) == 0 {
+				continue
+			}
+
+			// nested anyOf errors are also merged into the result
+			var list []string
+			for _, cause := range e.Causes {
+				list = append(list, cause.Pointer+" "+cause.KeywordLocation)
+			}
+			causes[e.KeywordLocation] = list
+
+			collect(e.Causes)
+		}
+	}
+	collect(result.Exceptions)
+
+	items := "/properties/services/items/properties"
+	want := map[string][]string{
+		// every failing branch, not only the closest one
+		items + "/port/anyOf": {
+			"/services/1/port " + items + "/port/anyOf/0/$ref/type",
+			"/services/1/port " + items + "/port/anyOf/1/$ref/pattern",
+		},
+		// "a" matches two branches, only the third fails
+		items + "/kind/oneOf": {
+			"/services/1/kind " + items + "/kind/oneOf/1/enum",
+		},
+		// a nested anyOf is a single cause, with causes of its own
+		items + "/mode/anyOf": {
+			"/services/1/mode " + items + "/mode/anyOf/0/type",
+			"/services/1/mode " + items + "/mode/anyOf/1/anyOf",
+		},
+		items + "/mode/anyOf/1/anyOf": {
+			"/services/1/mode " + items + "/mode/anyOf/1/anyOf/0/enum",
+			"/services/1/mode " + items + "/mode/anyOf/1/anyOf/1/enum",
+		},
+	}
+
+	if !reflect.DeepEqual(causes, want) {
+		t.Errorf("expected causes:\n%v\ngot:\n%v", want, causes)
+	}
+
+	// causes carry positions like any exception
+	for _, e := range result.Exceptions {
+		for _, cause := range e.Causes {
+			if cause.Line < 3 || cause.Line > 5 {
+				t.Errorf("expected the cause %q on lines 3 to 5, got %d", cause.ErrorString, cause.Line)
+			}
+		}
+	}
+}
+
+func TestBranchErrorsManyItems(t *testing.T) {
+	v, err := New(WithSchemaBytes("branches.json", branchSchema), WithCauses())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config strings.Builder
+	config.WriteString("services:\n")
+	for i := 0; i < 3000; i++ {
+		config.WriteString("- port: Web\n")
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "branches.yaml", []byte(config.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var anyOf []ExceptionDetail
+	for _, e := range result.Exceptions {
+		if e.Type == "number_any_of" {
+			anyOf = append(anyOf, e)
+		}
+	}
+
+	if len(anyOf) != 3000 {
+		t.Fatalf("expected an anyOf error per item, got %d", len(anyOf))
+	}
+
+	last := anyOf[len(anyOf)-1]
+	if len(last.Causes) != 2 {
+		t.Fatalf("expected two causes, got %+v", last.Causes)
+	}
+
+	for _, cause := range last.Causes {
+		if cause.Pointer != "/services/2999/port" || cause.Line != 3001 || !strings.HasPrefix(cause.ErrorString, "services.2999.port: ") {
+			t.Errorf("expected the cause on the last port, got %+v", cause)
+		}
+	}
+
+	// the branches are compiled once, whatever the number of items
+	if n := v.subschemas.len(); n != 2 {
+		t.Errorf("expected 2 compiled subschemas, got %d", n)
+	}
+
+	for i := 0; i < maxSubschemas+10; i++ {
+		if _, err := v.subschemas.compile(fmt.Sprint(i), v.bundle, map[string]interface{}{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := v.subschemas.len(); n != maxSubschemas {
+		t.Errorf("expected the cache bounded to %d subschemas, got %d", maxSubschemas, n)
+	}
+}
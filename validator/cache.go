@@ -0,0 +1,177 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaCache shares compiled schemas between Validators, e.g. across the
+// requests of a server. Schemas are keyed by a hash of their contents and
+// of every document they reference, so an unchanged schema is compiled
+// once while an edited one is compiled again. A SchemaCache is safe for
+// concurrent use.
+type SchemaCache struct {
+	mu      sync.Mutex
+	schemas map[string]*gojsonschema.Schema
+}
+
+// NewSchemaCache returns an empty cache.
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{schemas: map[string]*gojsonschema.Schema{}}
+}
+
+// Len returns the number of compiled schemas held by the cache.
+func (c *SchemaCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.schemas)
+}
+
+// compile returns the compiled schema of bundle, compiling it unless the
+// cache already holds it.
+func (c *SchemaCache) compile(bundle *schemaBundle, formats *FormatRegistry) (*gojsonschema.Schema, error) {
+	if c == nil {
+		return gojsonschema.NewSchema(bundle.New(bundle.root))
+	}
+
+	key, err := bundle.hash(formats)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	schema, ok := c.schemas[key]
+	c.mu.Unlock()
+
+	if ok {
+		return schema, nil
+	}
+
+	schema, err = gojsonschema.NewSchema(bundle.New(bundle.root))
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.schemas[key] = schema
+	c.mu.Unlock()
+
+	return schema, nil
+}
+
+// hash returns the SHA-256 of the documents of the bundle, and of the
+// format names they may use, which decide whether the schema compiles.
+func (b *schemaBundle) hash(formats *FormatRegistry) (string, error) {
+	uris := make([]string, 0, len(b.documents))
+	for uri := range b.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	h := sha256.New()
+
+	for _, name := range formats.Names() {
+		h.Write([]byte(name + "\x00"))
+	}
+
+	for _, uri := range uris {
+		// documents are decoded, so encoding them again is deterministic:
+		// object keys come out sorted
+		data, err := json.Marshal(b.documents[uri])
+		if err != nil {
+			return "", err
+		}
+
+		h.Write([]byte(uri + "\x00"))
+		h.Write(data)
+		h.Write([]byte("\x00"))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// maxSubschemas bounds the subschemas a Validator keeps compiled.
+const maxSubschemas = 256
+
+// subschemaCache holds the subschemas a Validator compiles while validating:
+// the branches of anyOf and oneOf, and the keywords given the values of
+// their $data references. Past its size, the least recently used subschema
+// is dropped, so that a long-lived Validator does not grow with its inputs.
+type subschemaCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// subschemaEntry is a compiled subschema of a subschemaCache.
+type subschemaEntry struct {
+	key    string
+	schema *gojsonschema.Schema
+}
+
+func newSubschemaCache(size int) *subschemaCache {
+	return &subschemaCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// compile returns the compiled schema of document, a subschema of bundle,
+// known by key, compiling it unless the cache already holds it.
+func (c *subschemaCache) compile(key string, bundle *schemaBundle, document interface{}) (*gojsonschema.Schema, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+
+		return e.Value.(*subschemaEntry).schema, nil
+	}
+	c.mu.Unlock()
+
+	schema, err := gojsonschema.NewSchema(&branchLoader{bundle: bundle, document: document})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.order.PushFront(&subschemaEntry{key: key, schema: schema})
+	}
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*subschemaEntry).key)
+	}
+
+	return schema, nil
+}
+
+// len returns the number of subschemas held by the cache.
+func (c *subschemaCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
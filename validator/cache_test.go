@@ -0,0 +1,92 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemaCache(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"root.json": `{"properties": {"node": {"$ref": "node.json"}}}`,
+		"node.json": `{"type": "object"}`,
+	})
+
+	cache := NewSchemaCache()
+
+	newValidator := func() *Validator {
+		v, err := New(WithSchemaFile(filepath.Join(dir, "root.json")), WithSchemaCache(cache))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	first, second := newValidator(), newValidator()
+
+	if first.schema != second.schema || cache.Len() != 1 {
+		t.Errorf("expected the compiled schema to be shared, got %d cached schemas", cache.Len())
+	}
+
+	// editing a referenced document compiles the schema again
+	if err := ioutil.WriteFile(filepath.Join(dir, "node.json"), []byte(`{"type": "array"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if third := newValidator(); third.schema == first.schema || cache.Len() != 2 {
+		t.Errorf("expected an edited schema to be compiled again, got %d cached schemas", cache.Len())
+	}
+
+	if uncached, err := New(WithSchemaFile(filepath.Join(dir, "root.json"))); err != nil {
+		t.Fatal(err)
+	} else if uncached.schema == first.schema {
+		t.Error("expected a Validator without cache to compile its own schema")
+	}
+}
+
+func BenchmarkNewKraken(b *testing.B) {
+	schema := krakenSchema(b)
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := New(WithSchemaFile(schema)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cache := NewSchemaCache()
+
+		for i := 0; i < b.N; i++ {
+			if _, err := New(WithSchemaFile(schema), WithSchemaCache(cache)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// krakenSchema returns the absolute path of the Kraken schema of the command
+// tests, a realistic schema split over several files.
+func krakenSchema(tb testing.TB) string {
+	path, err := filepath.Abs(filepath.Join("..", "cmd", "test_schemas", "kraken", "config.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return path
+}
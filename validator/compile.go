@@ -0,0 +1,163 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// CompileError reports a schema that gojsonschema rejects, e.g. a keyword
+// holding a value of the wrong type. gojsonschema does not tell where the
+// offending keyword is, so the error is located at the innermost schema
+// object that fails to compile on its own. Invalid values of the extension
+// keywords of the Validator are reported at the keyword.
+type CompileError struct {
+	// Schema is the name of the schema being compiled.
+	Schema string
+
+	// Location is the schema object at fault, as "uri#pointer". It is empty
+	// when the object could not be found.
+	Location string
+
+	// Line and Column locate the schema object in the source of its
+	// document. They are zero when the position is unknown.
+	Line   int
+	Column int
+
+	// Err is the error reported by gojsonschema, or the one found in the
+	// value of an extension keyword.
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	if e.Location == "" {
+		return fmt.Sprintf("invalid schema %s: %v", e.Schema, e.Err)
+	}
+
+	return fmt.Sprintf("invalid schema %s: %v, at %s%s", e.Schema, e.Err, e.Location, lineColumn(e.Line, e.Column))
+}
+
+// Unwrap returns the error reported by gojsonschema.
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// keywords whose value is a schema, an array of schemas, or an object whose
+// member values are schemas. items is either of the first two.
+var (
+	schemaKeywords = []string{
+		"additionalItems", "additionalProperties", "contains", "else", "if",
+		"items", "not", "propertyNames", "then",
+	}
+	schemaArrayKeywords = []string{"allOf", "anyOf", "items", "oneOf"}
+	schemaMapKeywords   = []string{"$defs", "definitions", "dependencies", "patternProperties", "properties"}
+)
+
+// compileError locates the cause of err, the error the root schema of the
+// bundle failed to compile with, and returns it as a *CompileError.
+func (b *schemaBundle) compileError(name string, err error) *CompileError {
+	compileErr := &CompileError{Schema: name, Err: err}
+
+	at := b.invalidSchema(location{document: b.root}, map[location]bool{})
+
+	compileErr.Location = at.String()
+	if span, ok := b.position(at, true); ok {
+		compileErr.Line = span.Line
+		compileErr.Column = span.Column
+	}
+
+	return compileErr
+}
+
+// invalidSchema returns the innermost schema object failing to compile
+// under at, which fails to compile itself. The schemas it holds and the
+// target of its $ref are tried in turn; seen guards against $ref cycles.
+func (b *schemaBundle) invalidSchema(at location, seen map[location]bool) location {
+	seen[at] = true
+
+	candidates := b.subschemas(at)
+	if site, ok := b.siteAt[at]; ok {
+		candidates = append(candidates, site.target)
+	}
+
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+
+		if _, err := gojsonschema.NewSchema(b.New(b.internalURI(candidate))); err != nil {
+			return b.invalidSchema(candidate, seen)
+		}
+	}
+
+	return at
+}
+
+// subschemas returns the locations of the schemas held directly by the
+// schema object at, in a stable order.
+func (b *schemaBundle) subschemas(at location) []location {
+	node, _ := resolvePointer(b.documents[at.document], at.pointer)
+
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var found []location
+
+	add := func(value interface{}, tokens ...string) {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return
+		}
+
+		pointer := at.pointer
+		for _, token := range tokens {
+			pointer = appendPointer(pointer, token)
+		}
+
+		found = append(found, location{document: at.document, pointer: pointer})
+	}
+
+	for _, keyword := range schemaKeywords {
+		add(object[keyword], keyword)
+	}
+
+	for _, keyword := range schemaArrayKeywords {
+		items, _ := object[keyword].([]interface{})
+		for i, item := range items {
+			add(item, keyword, strconv.Itoa(i))
+		}
+	}
+
+	for _, keyword := range schemaMapKeywords {
+		members, _ := object[keyword].(map[string]interface{})
+
+		names := make([]string, 0, len(members))
+		for name := range members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			add(members[name], keyword, name)
+		}
+	}
+
+	return found
+}
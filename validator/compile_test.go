@@ -0,0 +1,87 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompileErrors(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"nested.json": `{
+			"properties": {
+				"a": { "type": "string" },
+				"b": { "items": [ { "type": "string" }, { "minimum": "one" } ] }
+			}
+		}`,
+		"root.yaml": `properties:
+  ok:
+    type: string
+  port:
+    $ref: port.yaml
+`,
+		"port.yaml": `definitions:
+  unused:
+    type: integer
+anyOf:
+  - type: integer
+  - type: string
+    maxLength: -1
+`,
+		"root_itself.json": `{ "type": 5 }`,
+		"network.yaml": `properties:
+  network:
+    type: string
+  dns:
+    type: string
+    x-ip-within: up/network
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		schema   string
+		location string
+		line     int
+	}{
+		{"nested.json", "nested.json#/properties/b/items/1", 4},
+		{"root.yaml", "port.yaml#/anyOf/1", 6},
+		{"root_itself.json", "root_itself.json", 1},
+		{"network.yaml", "network.yaml#/properties/dns", 6},
+	}
+
+	for _, test := range tests {
+		_, err := New(WithSchemaFile(filepath.Join(dir, test.schema)))
+
+		schemaErr, ok := err.(*SchemaError)
+		if !ok {
+			t.Errorf("%s: expected a *SchemaError, got %v", test.schema, err)
+			continue
+		}
+
+		compileErr, ok := schemaErr.Err.(*CompileError)
+		if !ok {
+			t.Errorf("%s: expected a *CompileError, got %v", test.schema, schemaErr.Err)
+			continue
+		}
+
+		if !strings.HasSuffix(compileErr.Location, test.location) || compileErr.Line != test.line {
+			t.Errorf("%s: expected the error at %s on line %d, got %v", test.schema, test.location, test.line, compileErr)
+		}
+	}
+}
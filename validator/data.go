@@ -0,0 +1,245 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// A keyword may take its value from the document being validated, as in
+// {"minimum": {"$data": "1/from_port"}}: the relative or absolute JSON
+// Pointer is resolved from each instance the keyword applies to. gojsonschema
+// refuses such values, so they are taken out of the schema it is given, and
+// the format run checks them: for each instance, the keywords are given the
+// values they point to and handed to gojsonschema, so that errors are those
+// it reports for literal values. A pointer naming nothing leaves its
+// keyword out.
+
+// dataRefKeywords are the keywords whose value may be a $data reference.
+var dataRefKeywords = map[string]bool{
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"minItems": true, "maxItems": true, "minProperties": true, "maxProperties": true,
+	"enum": true, "const": true,
+}
+
+// dataPartners pairs the keywords gojsonschema only takes together: a
+// literal partner of a $data keyword is taken out of the schema with it.
+var dataPartners = map[string]string{
+	"minimum":          "exclusiveMinimum",
+	"exclusiveMinimum": "minimum",
+	"maximum":          "exclusiveMaximum",
+	"exclusiveMaximum": "maximum",
+}
+
+// dataRef returns the pointer of a {"$data": pointer} value.
+func dataRef(value interface{}) (interface{}, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok || len(object) != 1 {
+		return nil, false
+	}
+
+	ref, ok := object["$data"]
+
+	return ref, ok
+}
+
+// stripData removes from node, the schema object at pointer in the document
+// retrieved from uri, the keywords holding $data references and their
+// partners, and records them in b.data.
+func (b *schemaBundle) stripData(uri string, pointer string, node map[string]interface{}) {
+	var keywords []string
+	for key, value := range node {
+		if _, ok := dataRef(value); ok && dataRefKeywords[key] {
+			keywords = append(keywords, key)
+		}
+	}
+
+	if len(keywords) == 0 {
+		return
+	}
+
+	data := map[string]interface{}{}
+	for _, key := range keywords {
+		data[key] = node[key]
+		if partner, ok := dataPartners[key]; ok {
+			if value, ok := node[partner]; ok {
+				data[partner] = value
+			}
+		}
+	}
+
+	for key := range data {
+		delete(node, key)
+	}
+
+	b.data[location{document: uri, pointer: pointer}] = data
+}
+
+// checkDataRefs checks the $data references of the bundle, and returns a
+// *CompileError for the first that is not a JSON Pointer.
+func (b *schemaBundle) checkDataRefs(name string) error {
+	ats := make([]location, 0, len(b.data))
+	for at := range b.data {
+		ats = append(ats, at)
+	}
+	sort.Slice(ats, func(i, j int) bool { return ats[i].String() < ats[j].String() })
+
+	for _, at := range ats {
+		keywords := make([]string, 0, len(b.data[at]))
+		for keyword := range b.data[at] {
+			keywords = append(keywords, keyword)
+		}
+		sort.Strings(keywords)
+
+		for _, keyword := range keywords {
+			ref, ok := dataRef(b.data[at][keyword])
+			if !ok {
+				continue
+			}
+
+			if s, ok := ref.(string); ok && isDataPointer(s) {
+				continue
+			}
+
+			compileErr := &CompileError{
+				Schema:   name,
+				Location: at.String(),
+				Err:      fmt.Errorf("%s: $data %v is neither a JSON Pointer nor a relative JSON Pointer", keyword, ref),
+			}
+			if span, ok := b.position(location{document: at.document, pointer: appendPointer(appendPointer(at.pointer, keyword), "$data")}, true); ok {
+				compileErr.Line = span.Line
+				compileErr.Column = span.Column
+			}
+
+			return compileErr
+		}
+	}
+
+	return nil
+}
+
+// resolveData returns the keywords of p that hold $data references, with
+// the values they point to from the instance at pointer, as schemas
+// gojsonschema can check: const, which the draft 4 gojsonschema does not
+// know, is checked as an enum of one value, in a schema of its own.
+func (r *formatRun) resolveData(p schemaPath, pointer string) (map[string]interface{}, map[string]interface{}) {
+	resolved := map[string]interface{}{}
+
+	for keyword, value := range r.v.bundle.data[p.at] {
+		ref, ok := dataRef(value)
+		if !ok {
+			resolved[keyword] = value
+			continue
+		}
+
+		at, ok := dataPointer(pointer, ref.(string))
+		if !ok {
+			continue
+		}
+
+		if resolved[keyword], ok = resolvePointer(r.document, at); !ok {
+			delete(resolved, keyword)
+		}
+	}
+
+	// exclusiveMinimum and exclusiveMaximum do not compile alone
+	if _, ok := resolved["minimum"]; !ok {
+		delete(resolved, "exclusiveMinimum")
+	}
+	if _, ok := resolved["maximum"]; !ok {
+		delete(resolved, "exclusiveMaximum")
+	}
+
+	var constant map[string]interface{}
+	if value, ok := resolved["const"]; ok {
+		constant = map[string]interface{}{"enum": []interface{}{value}}
+		delete(resolved, "const")
+	}
+
+	return resolved, constant
+}
+
+// checkData returns the failures of value, the instance at pointer, against
+// the keywords of p holding $data references.
+func (r *formatRun) checkData(p schemaPath, pointer string, value interface{}) []formatFailure {
+	resolved, constant := r.resolveData(p, pointer)
+
+	failures := r.dataFailures(p, "", resolved, pointer, value)
+	failures = append(failures, r.dataFailures(p, "const", constant, pointer, value)...)
+
+	return failures
+}
+
+// dataFailures checks value, the instance at pointer, against schema, the
+// resolved keywords of p. alias is the keyword the schema stands for when
+// it is not the one gojsonschema reports.
+func (r *formatRun) dataFailures(p schemaPath, alias string, schema map[string]interface{}, pointer string, value interface{}) []formatFailure {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	// values of a type the keywords do not take leave them out
+	alone, err := r.v.dataSchema(schema)
+	if err != nil {
+		return nil
+	}
+
+	result, err := alone.Validate(&documentLoader{document: value})
+	if err != nil || result.Valid() {
+		return nil
+	}
+
+	owner := schemaPath{at: p.at, keywords: p.keywords, node: schema}
+
+	var failures []formatFailure
+	for _, desc := range result.Errors() {
+		failures = append(failures, formatFailure{owner: owner, keyword: alias, pointer: pointer, value: value, desc: rebaseError(desc, pointer)})
+	}
+
+	return failures
+}
+
+// dataSchema compiles schema, keywords given the values their $data
+// references point to. Compiled schemas are kept for the next instances
+// with the same values, within the bound of the subschema cache.
+func (v *Validator) dataSchema(schema map[string]interface{}) (*gojsonschema.Schema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.subschemas.compile("$data\x00"+string(data), v.bundle, schema)
+}
+
+// dataException describes the failure of a keyword given the value of its
+// $data reference as exception describes the errors of gojsonschema, at
+// the keyword written in the schema.
+func (v *Validator) dataException(ctx context.Context, name string, document interface{}, positions *positionIndex, failure formatFailure) ExceptionDetail {
+	exception := v.exception(ctx, name, document, positions, failure.owner, failure.pointer, failure.desc)
+
+	if failure.keyword != "" {
+		exception.Type = failure.keyword
+		exception.KeywordLocation, exception.AbsoluteKeywordLocation = failure.owner.keywordLocation(failure.keyword)
+		exception.units = v.bundle.units(exception.KeywordLocation, failure.pointer)
+	}
+
+	return exception
+}
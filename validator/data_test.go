@@ -0,0 +1,143 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDataReferences(t *testing.T) {
+	v, err := New(WithSchemaBytes("data.json", []byte(`{
+		"definitions": {
+			"rule": {
+				"properties": {
+					"from_port": { "type": "integer" },
+					"to_port": { "type": "integer", "minimum": { "$data": "1/from_port" } }
+				}
+			}
+		},
+		"properties": {
+			"egressAcl": { "items": { "$ref": "#/definitions/rule" } },
+			"pool": {
+				"properties": {
+					"maxCount": { "type": "integer" },
+					"strict": { "type": "boolean" },
+					"count": {
+						"maximum": { "$data": "1/maxCount" },
+						"exclusiveMaximum": { "$data": "1/strict" },
+						"multipleOf": { "$data": "/step" }
+					}
+				}
+			},
+			"users": { "type": "array" },
+			"default_user": { "enum": { "$data": "1/users" } },
+			"admin": { "const": { "$data": "/default_user" } },
+			"prefix": { "type": "string" },
+			"name": { "pattern": { "$data": "1/prefix" }, "maxLength": 8 }
+		}
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		errors   []string
+	}{
+		{`{"egressAcl": [{"from_port": 80, "to_port": 443}], "step": 1, "pool": {"maxCount": 5, "count": 5},
+		   "users": ["admin", "ops"], "default_user": "admin", "admin": "admin", "prefix": "^k8s-", "name": "k8s-a"}`, nil},
+		{`{"egressAcl": [{"from_port": 0, "to_port": 0}, {"from_port": 443, "to_port": 80}]}`,
+			[]string{"number_gte /egressAcl/1/to_port /properties/egressAcl/items/$ref/properties/to_port/minimum egressAcl.1.to_port: Must be greater than or equal to 443"}},
+		{`{"pool": {"maxCount": 5, "count": 6}}`,
+			[]string{"number_lte /pool/count /properties/pool/properties/count/maximum pool.count: Must be less than or equal to 5"}},
+		{`{"pool": {"maxCount": 5, "strict": true, "count": 5}}`,
+			[]string{"number_lt /pool/count /properties/pool/properties/count/maximum pool.count: Must be less than 5"}},
+		{`{"step": 2, "pool": {"count": 3}}`,
+			[]string{"multiple_of /pool/count /properties/pool/properties/count/multipleOf pool.count: Must be a multiple of 2"}},
+		{`{"users": ["ops"], "default_user": "admin"}`,
+			[]string{`enum /default_user /properties/default_user/enum default_user: default_user must be one of the following: "ops"`}},
+		{`{"default_user": "ops", "admin": "root"}`,
+			[]string{`const /admin /properties/admin/const admin: admin must be one of the following: "ops"`}},
+		{`{"prefix": "^k8s-", "name": "kube-apiserver"}`,
+			[]string{
+				"pattern /name /properties/name/pattern name: Does not match pattern '^k8s-'",
+				"string_lte /name /properties/name/maxLength name: String length must be less than or equal to 8",
+			}},
+
+		// pointers naming nothing, or values the keyword cannot take, leave it out
+		{`{"egressAcl": [{"to_port": 80}], "pool": {"count": 6, "strict": true}, "default_user": "admin"}`, nil},
+		{`{"pool": {"maxCount": "five", "count": 6}, "users": "ops", "default_user": "admin"}`,
+			[]string{
+				"invalid_type /pool/maxCount /properties/pool/properties/maxCount/type pool.maxCount: Invalid type. Expected: integer, given: string",
+				"invalid_type /users /properties/users/type users: Invalid type. Expected: array, given: string",
+			}},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "config.json", []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: %v", test.document, err)
+			continue
+		}
+
+		var errors []string
+		for _, e := range result.Exceptions {
+			errors = append(errors, e.Type+" "+e.Pointer+" "+e.KeywordLocation+" "+e.ErrorString)
+		}
+
+		if strings.Join(errors, "\n") != strings.Join(test.errors, "\n") || result.IsValid != (len(test.errors) == 0) {
+			t.Errorf("%s:\nexpected %v\ngot      %v", test.document, test.errors, errors)
+		}
+	}
+
+	// errors keep the path and position of the instance
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("egressAcl:\n  - from_port: 443\n    to_port: 80\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Exceptions) != 1 {
+		t.Fatalf("expected 1 exception, got %+v", result.Exceptions)
+	}
+	if e := result.Exceptions[0]; e.Path != "(root).egressAcl.0.to_port" || e.Line != 3 || e.Column != 14 {
+		t.Errorf("unexpected exception %+v", e)
+	}
+
+	// every distinct value compiles a schema, the cache keeps the latest
+	var acl strings.Builder
+	acl.WriteString("egressAcl:\n")
+	for i := 0; i < 2*maxSubschemas; i++ {
+		fmt.Fprintf(&acl, "  - {from_port: %d, to_port: 0}\n", i+1)
+	}
+
+	result, err = v.ValidateBytes(context.Background(), "config.yaml", []byte(acl.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Exceptions) != 2*maxSubschemas {
+		t.Errorf("expected an error per rule, got %d", len(result.Exceptions))
+	}
+	if n := v.subschemas.len(); n > maxSubschemas {
+		t.Errorf("expected at most %d compiled schemas, got %d", maxSubschemas, n)
+	}
+
+	if _, err := New(WithSchemaBytes("bad.json", []byte(`{"minimum": {"$data": "up/from_port"}}`))); err == nil {
+		t.Error("expected an invalid $data pointer to fail the schema")
+	}
+}
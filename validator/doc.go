@@ -0,0 +1,34 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validator validates YAML or JSON documents against a JSON schema
+// (draft 4). It holds everything the jsonsvalidator command does, without the
+// command line around it, so other Go programs can embed it directly:
+//
+//	v, err := validator.New(validator.WithSchemaFile("/path/to/schema.json"))
+//	if err != nil {
+//		return err
+//	}
+//
+//	result, err := v.ValidateFile(ctx, "/path/to/config.yaml")
+//	if err != nil {
+//		return err
+//	}
+//
+//	if !result.IsValid {
+//		for _, e := range result.Exceptions {
+//			fmt.Println(e.Path, e.ErrorString)
+//		}
+//	}
+package validator
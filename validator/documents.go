@@ -0,0 +1,102 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+)
+
+// yamlDocument is one document of a YAML stream: source[start:end], whose
+// first line is line. json is set for documents written in JSON.
+type yamlDocument struct {
+	start int
+	end   int
+	line  int
+	json  bool
+}
+
+// splitDocuments splits a YAML stream into its documents, on "---" and
+// "..." markers at the start of a line. Documents holding nothing but
+// comments, such as the one before a leading "---", are dropped; a stream
+// without any content is a single empty document. JSON is always a single
+// document.
+func splitDocuments(source []byte) []yamlDocument {
+	if isJSON(source) {
+		return []yamlDocument{{start: 0, end: len(source), line: 1, json: true}}
+	}
+
+	var documents []yamlDocument
+
+	current := yamlDocument{line: 1}
+	content := false
+
+	for offset, line := 0, 1; offset < len(source); line++ {
+		next := bytes.IndexByte(source[offset:], '\n')
+		if next < 0 {
+			next = len(source)
+		} else {
+			next += offset + 1
+		}
+
+		text := bytes.TrimRight(source[offset:next], "\r\n")
+
+		switch {
+		case isDocumentMarker(text, "---"):
+			if content {
+				current.end = offset
+				documents = append(documents, current)
+			}
+
+			// the marker line may carry the start of the document
+			current = yamlDocument{start: offset, line: line}
+			rest := bytes.TrimSpace(text[3:])
+			content = len(rest) > 0 && rest[0] != '#'
+
+		case isDocumentMarker(text, "..."):
+			if content {
+				current.end = offset
+				documents = append(documents, current)
+			}
+
+			current = yamlDocument{start: next, line: line + 1}
+			content = false
+
+		default:
+			trimmed := bytes.TrimSpace(text)
+			if len(trimmed) > 0 && trimmed[0] != '#' && trimmed[0] != '%' {
+				content = true
+			}
+		}
+
+		offset = next
+	}
+
+	if content {
+		current.end = len(source)
+		documents = append(documents, current)
+	}
+
+	if len(documents) == 0 {
+		documents = append(documents, yamlDocument{start: 0, end: len(source), line: 1})
+	}
+
+	return documents
+}
+
+// isDocumentMarker reports whether line is the marker, possibly followed by
+// blanks and more content.
+func isDocumentMarker(line []byte, marker string) bool {
+	return bytes.HasPrefix(line, []byte(marker)) && (len(line) == 3 || line[3] == ' ' || line[3] == '\t')
+}
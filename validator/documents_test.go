@@ -0,0 +1,108 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		lines  []int
+	}{
+		{"plain", "a: 1\n", []int{1}},
+		{"leading marker", "---\na: 1\n", []int{1}},
+		{"comments before the marker", "# header\n---\na: 1\n", []int{2}},
+		{"stream", "---\na: 1\n---\nb: 2\n...\n---\nc: 3\n", []int{1, 3, 6}},
+		{"empty documents", "---\n---\n# nothing\n---\na: 1\n---\n", []int{4}},
+		{"content on the marker line", "--- |\n  text\n--- # comment\nb: 2\n", []int{1, 3}},
+		{"markers inside block scalars", "a: |\n  --- not a marker\n", []int{1}},
+		{"empty stream", "# nothing\n", []int{1}},
+		{"json", "{\"a\": \"---\"}", []int{1}},
+	}
+
+	for _, test := range tests {
+		documents := splitDocuments([]byte(test.source))
+
+		var lines []int
+		for _, document := range documents {
+			lines = append(lines, document.line)
+		}
+
+		if len(lines) != len(test.lines) {
+			t.Errorf("%s: expected documents on lines %v, got %v", test.name, test.lines, lines)
+			continue
+		}
+
+		for i := range lines {
+			if lines[i] != test.lines[i] {
+				t.Errorf("%s: expected documents on lines %v, got %v", test.name, test.lines, lines)
+				break
+			}
+		}
+	}
+}
+
+func TestValidateDocuments(t *testing.T) {
+	stream := []byte("---\nname: a\n---\n# second\nname: b\ncount: 0\n---\nname: c\n")
+
+	v := newTestValidator(t)
+
+	results, err := v.ValidateDocuments(context.Background(), "stream.yaml", stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	for i, expected := range []struct {
+		valid bool
+		line  int
+	}{{true, 1}, {false, 3}, {true, 7}} {
+		result := results[i]
+		if result.IsValid != expected.valid || result.Document != i || result.Documents != 3 || result.StartLine != expected.line {
+			t.Errorf("document %d: expected valid=%v on line %d, got %+v", i, expected.valid, expected.line, result)
+		}
+	}
+
+	// positions are those in the whole stream
+	if e := results[1].Exceptions[0]; e.Line != 6 || e.Column != 8 {
+		t.Errorf("expected the exception on 6:8, got %d:%d", e.Line, e.Column)
+	}
+
+	if _, err := v.ValidateBytes(context.Background(), "stream.yaml", stream); err == nil {
+		t.Error("expected ValidateBytes to refuse a stream of several documents")
+	}
+
+	single := newTestValidator(t, WithSingleDocument())
+
+	if _, err := single.ValidateDocuments(context.Background(), "stream.yaml", stream); err == nil {
+		t.Error("expected WithSingleDocument to refuse a stream of several documents")
+	}
+
+	if _, err := single.ValidateDocuments(context.Background(), "one.yaml", []byte("---\nname: a\n")); err != nil {
+		t.Errorf("unexpected error for a single document: %v", err)
+	}
+
+	_, err = v.ValidateDocuments(context.Background(), "broken.yaml", []byte("name: a\n---\nname: [\n"))
+	if _, ok := err.(*ConfigError); !ok {
+		t.Errorf("expected a *ConfigError for an unparsable document, got %v", err)
+	}
+}
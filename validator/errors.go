@@ -0,0 +1,76 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+)
+
+// UsageError reports a Validator that was set up wrongly: an invalid option,
+// or no schema at all.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaError reports a schema, or a document it references, that could not
+// be loaded or compiled.
+type SchemaError struct {
+	Schema string
+	Err    error
+}
+
+func (e *SchemaError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error, e.g. a *RefError.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError reports a config document that could not be read or parsed.
+type ConfigError struct {
+	Config string
+	Err    error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidError reports a config document that was read and parsed but does
+// not satisfy its schema. It is returned by ValidatorResult.Err.
+type InvalidError struct {
+	Result *ValidatorResult
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("%s is not valid against %s: %d exception(s)",
+		e.Result.Config, e.Result.Schema, len(e.Result.Exceptions))
+}
@@ -0,0 +1,915 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The expressions of rules are boolean expressions over a document:
+//
+//	literals     1.5, 'text' or "text", true, false, null, [1, 2]
+//	paths        @ is the node a rule selected and $ the root of the
+//	             document; N levels above the node start with N, as
+//	             relative JSON Pointers do. Steps go on as in selectors:
+//	             @.count, $.clusters[0], 3.providerConfig['type'].
+//	             A path with a [*] or .* step gives the list of the values
+//	             it picks, any other path the value or null.
+//	operators    || && ! == != < <= > >= in + - * / %
+//	functions    len(x), sum(list), min(list), max(list),
+//	             matches(text, regexp)
+//
+// == compares values, numbers by value. <, <=, > and >= compare two
+// numbers or two strings, and are false for anything else, null included.
+// "x in y" tells whether x is an item of the list y, a key of the object y
+// or a substring of the string y.
+
+// expression is a compiled expression.
+type expression interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+// exprEnv is what an expression is evaluated against: a document and the
+// pointer of the node a rule selected in it.
+type exprEnv struct {
+	document interface{}
+	pointer  string
+}
+
+// parseExpression compiles the expression s.
+func parseExpression(s string) (expression, error) {
+	tokens, err := lexExpression(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.offset)
+	}
+
+	return e, nil
+}
+
+// evalBool evaluates e, which must give a boolean.
+func evalBool(e expression, env *exprEnv) (bool, error) {
+	value, err := e.eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %s", describeValue(value))
+	}
+
+	return b, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenPath
+	tokenOperator
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEnd {
+		return "end of expression"
+	}
+
+	return strconv.Quote(t.text)
+}
+
+// exprOperators are the operators and punctuation of expressions, longest
+// first.
+var exprOperators = []string{
+	"||", "&&", "==", "!=", "<=", ">=",
+	"!", "<", ">", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",",
+}
+
+// lexExpression splits s into tokens.
+func lexExpression(s string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '@' || c == '$' || isDigit(c) && startsRelativePath(s[i:]):
+			end := pathEnd(s, i)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated path at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenPath, s[i:end], i})
+			i = end
+
+		case isDigit(c) || c == '.' && i+1 < len(s) && isDigit(s[i+1]):
+			end := i
+			for end < len(s) && (isDigit(s[end]) || s[end] == '.') {
+				end++
+			}
+			if end < len(s) && (s[end] == 'e' || s[end] == 'E') {
+				end++
+				if end < len(s) && (s[end] == '+' || s[end] == '-') {
+					end++
+				}
+				for end < len(s) && isDigit(s[end]) {
+					end++
+				}
+			}
+			tokens = append(tokens, token{tokenNumber, s[i:end], i})
+			i = end
+
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenString, s[i+1 : i+1+end], i})
+			i += end + 2
+
+		case isIdentStart(c):
+			end := i
+			for end < len(s) && isIdentPart(s[end]) {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, s[i:end], i})
+			i = end
+
+		default:
+			op := ""
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				r, _ := utf8.DecodeRuneInString(s[i:])
+				return nil, fmt.Errorf("unexpected %q at offset %d", r, i)
+			}
+			tokens = append(tokens, token{tokenOperator, op, i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{tokenEnd, "", len(s)}), nil
+}
+
+// startsRelativePath tells whether s, starting with a digit, is a path such
+// as 3.providerConfig rather than a number: its digits are followed by a
+// member or index step.
+func startsRelativePath(s string) bool {
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	rest := s[digits:]
+
+	return strings.HasPrefix(rest, "[") ||
+		strings.HasPrefix(rest, ".") && len(rest) > 1 && (isIdentStart(rest[1]) || rest[1] == '*')
+}
+
+// pathEnd returns the offset just past the path starting at s[start], or -1
+// when a bracket is left open.
+func pathEnd(s string, start int) int {
+	i := start + 1
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	for i < len(s) {
+		switch {
+		case s[i] == '.' && i+1 < len(s) && (isIdentPart(s[i+1]) || s[i+1] == '*'):
+			i++
+			if s[i] == '*' {
+				i++
+				continue
+			}
+			for i < len(s) && isIdentPart(s[i]) {
+				i++
+			}
+
+		case s[i] == '[':
+			end := i + 1
+			if end < len(s) && (s[end] == '\'' || s[end] == '"') {
+				quote := strings.IndexByte(s[end+1:], s[end])
+				if quote < 0 {
+					return -1
+				}
+				end += quote + 2
+			}
+			close := strings.IndexByte(s[end:], ']')
+			if close < 0 {
+				return -1
+			}
+			i = end + close + 1
+
+		default:
+			return i
+		}
+	}
+
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// exprParser parses tokens by recursive descent, from the operators binding
+// the least to those binding the most.
+type exprParser struct {
+	tokens []token
+	next   int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.next]
+}
+
+// accept consumes the next token when it is one of the operators ops.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator && !(t.kind == tokenIdent && t.text == "in") {
+		return "", false
+	}
+
+	for _, op := range ops {
+		if t.text == op {
+			p.next++
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return fmt.Errorf("expected %q, got %s at offset %d", op, t, t.offset)
+	}
+
+	return nil
+}
+
+func (p *exprParser) or() (expression, error) {
+	return p.binary(p.and, "||")
+}
+
+func (p *exprParser) and() (expression, error) {
+	return p.binary(p.comparison, "&&")
+}
+
+func (p *exprParser) comparison() (expression, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">", "in")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	return &binaryExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *exprParser) additive() (expression, error) {
+	return p.binary(p.multiplicative, "+", "-")
+}
+
+func (p *exprParser) multiplicative() (expression, error) {
+	return p.binary(p.unary, "*", "/", "%")
+}
+
+// binary parses left-associative operators ops between operands.
+func (p *exprParser) binary(operand func() (expression, error), ops ...string) (expression, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) unary() (expression, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unaryExpr{op: op, operand: operand}, nil
+	}
+
+	return p.primary()
+}
+
+func (p *exprParser) primary() (expression, error) {
+	t := p.peek()
+	p.next++
+
+	switch t.kind {
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at offset %d", t, t.offset)
+		}
+		return literalExpr{f}, nil
+
+	case tokenString:
+		return literalExpr{t.text}, nil
+
+	case tokenPath:
+		return parsePathExpr(t)
+
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return literalExpr{t.text == "true"}, nil
+		case "null":
+			return literalExpr{nil}, nil
+		}
+		return p.call(t)
+
+	case tokenOperator:
+		switch t.text {
+		case "(":
+			e, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			return e, p.expect(")")
+
+		case "[":
+			list := &listExpr{}
+			if _, ok := p.accept("]"); ok {
+				return list, nil
+			}
+			for {
+				item, err := p.or()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+
+				if _, ok := p.accept(","); !ok {
+					return list, p.expect("]")
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.offset)
+}
+
+// call parses the call of the function named by t.
+func (p *exprParser) call(t token) (expression, error) {
+	fn, ok := exprFunctions[t.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at offset %d", t, t.offset)
+	}
+
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	c := &callExpr{name: t.text, fn: fn}
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			c.args = append(c.args, arg)
+
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.args) != fn.arity {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d at offset %d", t.text, fn.arity, len(c.args), t.offset)
+	}
+
+	// patterns are compiled once when they are literals
+	if t.text == "matches" {
+		if pattern, ok := c.args[1].(literalExpr); ok {
+			s, ok := pattern.value.(string)
+			if !ok {
+				return nil, fmt.Errorf("matches takes a regexp, got %s at offset %d", describeValue(pattern.value), t.offset)
+			}
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regexp at offset %d: %v", t.offset, err)
+			}
+			c.re = re
+		}
+	}
+
+	return c, nil
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(env *exprEnv) (interface{}, error) {
+	return e.value, nil
+}
+
+type listExpr struct {
+	items []expression
+}
+
+func (e *listExpr) eval(env *exprEnv) (interface{}, error) {
+	list := make([]interface{}, 0, len(e.items))
+
+	for _, item := range e.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+
+	return list, nil
+}
+
+// pathExpr picks values of the document with a selector.
+type pathExpr struct {
+	sel selector
+	all bool
+}
+
+// parsePathExpr compiles the path of t. @ stands for the selected node,
+// zero levels above it.
+func parsePathExpr(t token) (expression, error) {
+	text := t.text
+	if strings.HasPrefix(text, "@") {
+		text = "0" + text[1:]
+	}
+
+	sel, err := parseSelector(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path at offset %d: %v", t.offset, err)
+	}
+
+	e := &pathExpr{sel: sel}
+	for _, step := range sel.steps {
+		e.all = e.all || step.all
+	}
+
+	return e, nil
+}
+
+func (e *pathExpr) eval(env *exprEnv) (interface{}, error) {
+	base, ok := e.sel.base(env.pointer)
+
+	var picked []selected
+	if ok {
+		picked = e.sel.apply(env.document, base)
+	}
+
+	if !e.all {
+		if len(picked) == 0 {
+			return nil, nil
+		}
+		return plainValue(picked[0].value), nil
+	}
+
+	list := make([]interface{}, 0, len(picked))
+	for _, s := range picked {
+		list = append(list, plainValue(s.value))
+	}
+
+	return list, nil
+}
+
+// plainValue returns value with its json.Numbers turned into float64, the
+// numbers of expressions.
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = plainValue(item)
+		}
+		return m
+
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = plainValue(item)
+		}
+		return list
+	}
+
+	return value
+}
+
+type unaryExpr struct {
+	op      string
+	operand expression
+}
+
+func (e *unaryExpr) eval(env *exprEnv) (interface{}, error) {
+	if e.op == "!" {
+		b, err := evalBool(e.operand, env)
+		return !b, err
+	}
+
+	value, err := e.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate %s", describeValue(value))
+	}
+
+	return -f, nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right expression
+}
+
+func (e *binaryExpr) eval(env *exprEnv) (interface{}, error) {
+	switch e.op {
+	case "&&", "||":
+		left, err := evalBool(e.left, env)
+		if err != nil || left == (e.op == "||") {
+			return left, err
+		}
+		return evalBool(e.right, env)
+	}
+
+	left, err := e.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := e.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return valueKey(left) == valueKey(right), nil
+	case "!=":
+		return valueKey(left) != valueKey(right), nil
+	case "<", "<=", ">", ">=":
+		return compareValues(e.op, left, right), nil
+	case "in":
+		return contains(right, left), nil
+	}
+
+	return arithmetic(e.op, left, right)
+}
+
+// compareValues orders two numbers or two strings.
+func compareValues(op string, left interface{}, right interface{}) bool {
+	var order int
+
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case l < r:
+			order = -1
+		case l > r:
+			order = 1
+		}
+
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		order = strings.Compare(l, r)
+
+	default:
+		return false
+	}
+
+	switch op {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	}
+
+	return order >= 0
+}
+
+// contains tells whether item is an item of the list, a key of the object
+// or a substring of the string container.
+func contains(container interface{}, item interface{}) bool {
+	switch c := container.(type) {
+	case []interface{}:
+		key := valueKey(item)
+		for _, value := range c {
+			if valueKey(value) == key {
+				return true
+			}
+		}
+
+	case map[string]interface{}:
+		if s, ok := item.(string); ok {
+			_, found := c[s]
+			return found
+		}
+
+	case string:
+		if s, ok := item.(string); ok {
+			return strings.Contains(c, s)
+		}
+	}
+
+	return false
+}
+
+// arithmetic applies +, -, *, / or % to numbers. + also concatenates
+// strings and lists.
+func arithmetic(op string, left interface{}, right interface{}) (interface{}, error) {
+	if op == "+" {
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		case []interface{}:
+			if r, ok := right.([]interface{}); ok {
+				return append(append([]interface{}{}, l...), r...), nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", op, describeValue(left), describeValue(right))
+	}
+
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	}
+
+	if r == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+
+	if op == "%" {
+		return math.Mod(l, r), nil
+	}
+
+	return l / r, nil
+}
+
+// exprFunction is a function expressions may call.
+type exprFunction struct {
+	arity int
+	call  func(c *callExpr, args []interface{}) (interface{}, error)
+}
+
+var exprFunctions = map[string]exprFunction{
+	"len":     {1, callLen},
+	"sum":     {1, callSum},
+	"min":     {1, callMin},
+	"max":     {1, callMax},
+	"matches": {2, callMatches},
+}
+
+type callExpr struct {
+	name string
+	fn   exprFunction
+	args []expression
+
+	// re is the compiled pattern of matches, when it is a literal.
+	re *regexp.Regexp
+}
+
+func (e *callExpr) eval(env *exprEnv) (interface{}, error) {
+	args := make([]interface{}, 0, len(e.args))
+
+	for _, arg := range e.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	value, err := e.fn.call(e, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", e.name, err)
+	}
+
+	return value, nil
+}
+
+// callLen gives the number of characters of a string, items of a list or
+// members of an object; null has none.
+func callLen(c *callExpr, args []interface{}) (interface{}, error) {
+	switch v := args[0].(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+
+	return nil, fmt.Errorf("expected a string, list or object, got %s", describeValue(args[0]))
+}
+
+// numbers returns the numbers of a list, leaving nulls out.
+func numbers(value interface{}) ([]float64, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %s", describeValue(value))
+	}
+
+	var numbers []float64
+	for _, item := range list {
+		switch n := item.(type) {
+		case nil:
+		case float64:
+			numbers = append(numbers, n)
+		default:
+			return nil, fmt.Errorf("expected numbers, got %s", describeValue(item))
+		}
+	}
+
+	return numbers, nil
+}
+
+func callSum(c *callExpr, args []interface{}) (interface{}, error) {
+	list, err := numbers(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	sum := float64(0)
+	for _, n := range list {
+		sum += n
+	}
+
+	return sum, nil
+}
+
+// callMin gives the smallest number of a list, or null for an empty one.
+func callMin(c *callExpr, args []interface{}) (interface{}, error) {
+	return extreme(args[0], func(a, b float64) bool { return a < b })
+}
+
+// callMax gives the largest number of a list, or null for an empty one.
+func callMax(c *callExpr, args []interface{}) (interface{}, error) {
+	return extreme(args[0], func(a, b float64) bool { return a > b })
+}
+
+func extreme(value interface{}, better func(a, b float64) bool) (interface{}, error) {
+	list, err := numbers(value)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+
+	best := list[0]
+	for _, n := range list[1:] {
+		if better(n, best) {
+			best = n
+		}
+	}
+
+	return best, nil
+}
+
+// callMatches tells whether a string matches a regexp. Anything but a
+// string does not.
+func callMatches(c *callExpr, args []interface{}) (interface{}, error) {
+	re := c.re
+	if re == nil {
+		pattern, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a regexp, got %s", describeValue(args[1]))
+		}
+
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	s, ok := args[0].(string)
+
+	return ok && re.MatchString(s), nil
+}
+
+// describeValue names the type of an expression value, with the value
+// itself when it is short.
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean " + strconv.FormatBool(v)
+	case float64:
+		return "number " + strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return "string " + strconv.Quote(v)
+	case []interface{}:
+		return "a list"
+	}
+
+	return "an object"
+}
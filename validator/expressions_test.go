@@ -0,0 +1,152 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExpressions(t *testing.T) {
+	document, err := decodeYAML([]byte(`
+clusters:
+  - name: production
+    provider: {type: aws}
+    nodePools:
+      - {name: etcd, count: 3, type: m3.medium}
+      - {name: workers, count: 10, type: n1-standard-1}
+  - name: staging
+    nodePools: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expression string
+		pointer    string
+		expected   interface{}
+	}{
+		{"1 + 2 * 3 - 4 / 2", "", 5.0},
+		{"(1 + 2) * 3 % 4", "", 1.0},
+		{"-@.count", "/clusters/0/nodePools/1", -10.0},
+		{"'a' + \"b\" == 'ab'", "", true},
+		{"[1, 2] + [3] == [1, 2, 3]", "", true},
+		{"1 == 1.0 && 1 != '1'", "", true},
+		{"!true || false", "", false},
+		{"null == @.missing", "", true},
+		{"@.missing < 1 || @.missing >= 1", "", false},
+		{"'abc' < 'abd' && 2 <= 2 && 3 > 2.5", "", true},
+
+		{"$.clusters[0].name", "/clusters/0/nodePools/1", "production"},
+		{"$.clusters[*].name", "", []interface{}{"production", "staging"}},
+		{"$.clusters[*].nodePools[*].count", "", []interface{}{3.0, 10.0}},
+		{"@.count", "/clusters/0/nodePools/0", 3.0},
+		{"@['name']", "/clusters/0/nodePools/1", "workers"},
+		{"2.name", "/clusters/0/nodePools/1", "production"},
+		{"2.provider.type == 'aws'", "/clusters/0/nodePools/0", true},
+		{"9.name", "/clusters/0", nil},
+		{"@.nodePools[*].count", "/clusters/1", []interface{}{}},
+
+		{"len(@.name) + len($.clusters) + len(@.nodePools[0]) + len(@.missing)", "/clusters/0", 15.0},
+		{"sum($.clusters[*].nodePools[*].count)", "", 13.0},
+		{"min($.clusters[*].nodePools[*].count) + max($.clusters[*].nodePools[*].count)", "", 13.0},
+		{"max(@.nodePools[*].count)", "/clusters/1", nil},
+		{"matches(@.type, '^[a-z][0-9]\\.[a-z]+$')", "/clusters/0/nodePools/0", true},
+		{"matches(@.type, '^[a-z]' + '[0-9]\\.')", "/clusters/0/nodePools/1", false},
+		{"matches(@.count, '3')", "/clusters/0/nodePools/0", false},
+
+		{"'etcd' in $.clusters[*].nodePools[*].name", "", true},
+		{"'type' in @ && 'size' in @", "/clusters/0/nodePools/0", false},
+		{"'duct' in 'production'", "/clusters/0", true},
+		{"3 in 3", "", false},
+	}
+
+	for _, test := range tests {
+		e, err := parseExpression(test.expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+
+		value, err := e.eval(&exprEnv{document: document, pointer: test.pointer})
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+
+		if fmt.Sprint(value) != fmt.Sprint(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.expression, test.expected, value)
+		}
+	}
+
+	failures := []struct {
+		expression string
+		err        string
+	}{
+		{"", "unexpected end of expression at offset 0"},
+		{"1 +", "unexpected end of expression at offset 3"},
+		{"(1", `expected ")", got end of expression at offset 2`},
+		{"1 2", `unexpected "2" at offset 2`},
+		{"'open", "unterminated string at offset 0"},
+		{"@['open", "unterminated path at offset 0"},
+		{"1 # 2", `unexpected '#' at offset 2`},
+		{"size(@)", `unknown function "size" at offset 0`},
+		{"len(1, 2)", "len takes 1 argument(s), got 2 at offset 0"},
+		{"matches(@, '[')", "invalid regexp at offset 0: error parsing regexp: missing closing ]: `[`"},
+		{"matches(@, 1)", "matches takes a regexp, got number 1 at offset 0"},
+		{"@[x]", `invalid path at offset 0: invalid index "x" in "0[x]"`},
+	}
+
+	for _, test := range failures {
+		_, err := parseExpression(test.expression)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.expression, test.err, err)
+		}
+	}
+
+	evalFailures := []struct {
+		expression string
+		err        string
+	}{
+		{"@.name && true", `expected a boolean, got string "production"`},
+		{"!1", "expected a boolean, got number 1"},
+		{"@.name + 1", `cannot apply + to string "production" and number 1`},
+		{"-@.nodePools", "cannot negate a list"},
+		{"1 / 0", "division by zero"},
+		{"len(true)", "len: expected a string, list or object, got boolean true"},
+		{"sum(@.name)", `sum: expected a list, got string "production"`},
+		{"sum($.clusters[*].name)", `sum: expected numbers, got string "production"`},
+	}
+
+	for _, test := range evalFailures {
+		e, err := parseExpression(test.expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+
+		_, err = evalBool(e, &exprEnv{document: document, pointer: "/clusters/0"})
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.expression, test.err, err)
+		}
+	}
+
+	// expressions must give booleans
+	e, _ := parseExpression("@.name")
+	if _, err := evalBool(e, &exprEnv{document: document, pointer: "/clusters/0"}); err == nil {
+		t.Error("expected a string expression to be refused")
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Besides formats, the Validator checks keywords of its own, such as
+// x-cidr-within. gojsonschema ignores keywords it does not know, so they
+// are left in the schema it is given; stripFormats records where they are
+// and the format run checks them with the formats. Unlike the standard
+// keywords, they are checked next to a $ref too.
+
+// extensionKeyword is a keyword checked by the Validator.
+type extensionKeyword struct {
+	// compile checks the value of the keyword when the schema is loaded.
+	compile func(value interface{}) error
+
+	// check returns the failures of value, the instance at pointer, against
+	// the keyword of the schema p.
+	check func(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure
+}
+
+// extensionKeywords are the keywords checked by the Validator, by name.
+// The files defining them add them from their init functions.
+var extensionKeywords = map[string]extensionKeyword{}
+
+// checkExtensions checks the values of the extension keywords of the
+// bundle, and returns a *CompileError for the first invalid one.
+func (b *schemaBundle) checkExtensions(name string) error {
+	ats := make([]location, 0, len(b.extensions))
+	for at := range b.extensions {
+		ats = append(ats, at)
+	}
+	sort.Slice(ats, func(i, j int) bool { return ats[i].String() < ats[j].String() })
+
+	for _, at := range ats {
+		node, _ := resolvePointer(b.documents[at.document], at.pointer)
+		object, _ := node.(map[string]interface{})
+
+		for _, keyword := range b.extensions[at] {
+			err := extensionKeywords[keyword].compile(object[keyword])
+			if err == nil {
+				continue
+			}
+
+			compileErr := &CompileError{Schema: name, Location: at.String(), Err: fmt.Errorf("%s: %v", keyword, err)}
+			if span, ok := b.position(location{document: at.document, pointer: appendPointer(at.pointer, keyword)}, true); ok {
+				compileErr.Line = span.Line
+				compileErr.Column = span.Column
+			}
+
+			return compileErr
+		}
+	}
+
+	return nil
+}
+
+// referenced returns the string that ref, an absolute or relative JSON
+// Pointer, names from the instance at pointer, with its absolute pointer.
+func (r *formatRun) referenced(pointer string, ref string) (string, string, bool) {
+	at, ok := dataPointer(pointer, ref)
+	if !ok {
+		return "", "", false
+	}
+
+	value, ok := resolvePointer(r.document, at)
+	if !ok {
+		return "", "", false
+	}
+
+	text, ok := value.(string)
+
+	return text, at, ok
+}
+
+// compileItemPointer accepts the value of a keyword naming a value of each
+// item of the array instance: true for the item itself, or a relative JSON
+// Pointer from the item.
+func compileItemPointer(value interface{}) error {
+	if value == true {
+		return nil
+	}
+
+	ref, ok := value.(string)
+	if !ok || ref == "" || ref[0] == '/' || !isDataPointer(ref) {
+		return fmt.Errorf("expected true or a relative JSON Pointer, got %v", value)
+	}
+
+	return nil
+}
+
+// itemPointer returns the relative JSON Pointer from each item that the
+// value of a keyword accepted by compileItemPointer stands for.
+func itemPointer(value interface{}) string {
+	if ref, ok := value.(string); ok {
+		return ref
+	}
+
+	return "0"
+}
+
+// compileDataPointer accepts the value of a keyword naming a value of the
+// instance document by an absolute or relative JSON Pointer.
+func compileDataPointer(value interface{}) error {
+	ref, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a JSON Pointer, got %v", value)
+	}
+
+	if !isDataPointer(ref) {
+		return fmt.Errorf("%q is neither a JSON Pointer nor a relative JSON Pointer", ref)
+	}
+
+	return nil
+}
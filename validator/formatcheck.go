@@ -0,0 +1,543 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// The vendored gojsonschema only knows a process-wide chain of format
+// checkers, which it reads while validating; adding to it races with any
+// validation running at the same time. It also checks numbers against
+// checkers written for strings. So the format keywords naming a format of
+// the Validator's registry or a built-in format are taken out of the schema
+// handed to gojsonschema, and the Validator checks them itself once
+// gojsonschema is done, walking the schema down the instance the way
+// gojsonschema does.
+
+// stripFormats removes from the schemas of the bundle the format keywords
+// naming a format of formats or a built-in format, and records them in
+// b.formats. It records the extension keywords of each schema object in
+// b.extensions, and moves the keywords holding $data references to b.data.
+func (b *schemaBundle) stripFormats(formats *FormatRegistry) {
+	b.formats = map[location]string{}
+	b.extensions = map[location][]string{}
+	b.data = map[location]map[string]interface{}{}
+
+	uris := make([]string, 0, len(b.documents))
+	for uri := range b.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		b.stripNode(uri, b.documents[uri], "", formats)
+	}
+
+	b.markChecked()
+}
+
+func (b *schemaBundle) stripNode(uri string, node interface{}, pointer string, formats *FormatRegistry) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if name, ok := n["format"].(string); ok {
+			if _, ok := formats.resolve(name); ok {
+				b.formats[location{document: uri, pointer: pointer}] = name
+				delete(n, "format")
+			}
+		}
+
+		b.stripData(uri, pointer, n)
+
+		var extensions []string
+		for key := range n {
+			if _, ok := extensionKeywords[key]; ok {
+				extensions = append(extensions, key)
+			}
+		}
+		if len(extensions) > 0 {
+			sort.Strings(extensions)
+			b.extensions[location{document: uri, pointer: pointer}] = extensions
+		}
+
+		schemaChildren(n, pointer, func(pointer string, value interface{}) {
+			b.stripNode(uri, value, pointer, formats)
+		})
+
+	case []interface{}:
+		for i, item := range n {
+			b.stripNode(uri, item, appendPointer(pointer, strconv.Itoa(i)), formats)
+		}
+	}
+}
+
+// markChecked records in b.checked every location from which a stripped
+// format, an extension keyword or a $data reference can be reached, through nested schemas or
+// $refs, so that schemas without any are not walked.
+func (b *schemaBundle) markChecked() {
+	b.checked = map[location]bool{}
+
+	// mark at and the locations holding it
+	mark := func(at location) {
+		for pointer := at.pointer; ; {
+			l := location{document: at.document, pointer: pointer}
+			if b.checked[l] {
+				return
+			}
+			b.checked[l] = true
+
+			if pointer == "" {
+				return
+			}
+			pointer = parentPointer(pointer)
+		}
+	}
+
+	for at := range b.formats {
+		mark(at)
+	}
+	for at := range b.extensions {
+		mark(at)
+	}
+	for at := range b.data {
+		mark(at)
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, site := range b.sites {
+			if b.checked[site.target] && !b.checked[site.at] {
+				mark(site.at)
+				changed = true
+			}
+		}
+	}
+}
+
+// formatFailure is an instance failing a stripped format keyword or an
+// extension keyword, or an anyOf or oneOf that fails because of those of
+// its branches.
+type formatFailure struct {
+	owner   schemaPath
+	keyword string
+	pointer string
+	value   interface{}
+	causes  []formatFailure
+
+	// errorType, text and params give the error type, default message
+	// template and parameters of the failures of extension keywords; the
+	// others take those gojsonschema reports them with. params also holds
+	// the format of format failures.
+	errorType string
+	text      string
+	params    map[string]interface{}
+
+	// desc is the error gojsonschema reported for a keyword given the value
+	// of its $data reference.
+	desc gojsonschema.ResultError
+}
+
+// formatRun checks the stripped formats of one document.
+type formatRun struct {
+	v        *Validator
+	ctx      context.Context
+	document interface{}
+
+	// moot lists the oneOf and not errors of gojsonschema that the formats
+	// overturn, keyed by exceptionLocation.
+	moot map[string]bool
+
+	// active guards against $refs leading back to a schema already being
+	// applied to the same instance. A schema applied again to a nested
+	// instance, as recursive schemas are, is not a loop.
+	active map[activeSchema]bool
+}
+
+// activeSchema is a schema being applied to the instance at pointer.
+type activeSchema struct {
+	at      location
+	pointer string
+}
+
+func (v *Validator) newFormatRun(ctx context.Context, document interface{}) *formatRun {
+	return &formatRun{v: v, ctx: ctx, document: document, moot: map[string]bool{}, active: map[activeSchema]bool{}}
+}
+
+// evaluate returns the format failures of value, the instance at pointer,
+// against the schema p.
+func (r *formatRun) evaluate(p schemaPath, pointer string, value interface{}) []formatFailure {
+	b := r.v.bundle
+
+	key := activeSchema{at: p.at, pointer: pointer}
+	if !b.checked[p.at] || r.active[key] || r.ctx.Err() != nil {
+		return nil
+	}
+
+	r.active[key] = true
+	defer delete(r.active, key)
+
+	var failures []formatFailure
+
+	for _, keyword := range b.extensions[p.at] {
+		failures = append(failures, extensionKeywords[keyword].check(r, p, pointer, value)...)
+	}
+
+	if _, ok := p.node["$ref"]; ok {
+		if target, ok := b.refTarget(p); ok {
+			failures = append(failures, r.evaluate(target, pointer, value)...)
+		}
+
+		// draft 4 ignores the siblings of a $ref
+		return failures
+	}
+
+	// gojsonschema goes no further into a schema the instance is not of the
+	// type of
+	if !typeMatches(p.node["type"], value) {
+		return nil
+	}
+
+	if name, ok := b.formats[p.at]; ok && !r.v.formats.check(name, value) {
+		failures = append(failures, formatFailure{owner: p, keyword: "format", pointer: pointer, value: value,
+			params: map[string]interface{}{"format": name}})
+	}
+
+	if _, ok := b.data[p.at]; ok {
+		failures = append(failures, r.checkData(p, pointer, value)...)
+	}
+
+	for _, branch := range p.branches("allOf") {
+		failures = append(failures, r.evaluate(branch, pointer, value)...)
+	}
+
+	failures = append(failures, r.anyOf(p, pointer, value)...)
+	failures = append(failures, r.oneOf(p, pointer, value)...)
+	r.not(p, pointer, value)
+
+	switch instance := value.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(instance))
+		for name := range instance {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if dependencies, ok := p.node["dependencies"].(map[string]interface{}); ok {
+			for _, name := range names {
+				failures = append(failures, r.evaluateAll(p.appendChild(nil, dependencies[name], "dependencies", name), pointer, value)...)
+			}
+		}
+
+		for _, name := range names {
+			failures = append(failures, r.evaluateAll(b.memberSchemas(p, name), appendPointer(pointer, name), instance[name])...)
+		}
+
+	case []interface{}:
+		for i, item := range instance {
+			failures = append(failures, r.evaluateAll(b.itemSchemas(p, i), appendPointer(pointer, strconv.Itoa(i)), item)...)
+		}
+	}
+
+	return failures
+}
+
+func (r *formatRun) evaluateAll(paths []schemaPath, pointer string, value interface{}) []formatFailure {
+	var failures []formatFailure
+
+	for _, p := range paths {
+		failures = append(failures, r.evaluate(p, pointer, value)...)
+	}
+
+	return failures
+}
+
+// anyOf fails the anyOf of p when every branch gojsonschema accepted fails
+// its formats. When gojsonschema accepted none, it reported the anyOf
+// already.
+func (r *formatRun) anyOf(p schemaPath, pointer string, value interface{}) []formatFailure {
+	branches := p.branches("anyOf")
+	if !r.v.bundle.anyChecked(branches) {
+		return nil
+	}
+
+	var causes []formatFailure
+	accepted := false
+
+	for _, branch := range branches {
+		if !r.valid(branch, value) {
+			continue
+		}
+
+		accepted = true
+
+		failures := r.evaluate(branch, pointer, value)
+		if len(failures) == 0 {
+			return nil
+		}
+		causes = append(causes, failures...)
+	}
+
+	if !accepted {
+		return nil
+	}
+
+	return []formatFailure{{owner: p, keyword: "anyOf", pointer: pointer, value: value, causes: causes}}
+}
+
+// oneOf fails the oneOf of p when the formats reject the one branch
+// gojsonschema accepted, and overturns the error of gojsonschema when they
+// reject all but one of the branches it accepted.
+func (r *formatRun) oneOf(p schemaPath, pointer string, value interface{}) []formatFailure {
+	branches := p.branches("oneOf")
+	if !r.v.bundle.anyChecked(branches) {
+		return nil
+	}
+
+	var causes []formatFailure
+	accepted, passed := 0, 0
+
+	for _, branch := range branches {
+		if !r.valid(branch, value) {
+			continue
+		}
+
+		accepted++
+
+		failures := r.evaluate(branch, pointer, value)
+		if len(failures) == 0 {
+			passed++
+		}
+		causes = append(causes, failures...)
+	}
+
+	switch {
+	case accepted == 1 && passed == 0:
+		return []formatFailure{{owner: p, keyword: "oneOf", pointer: pointer, value: value, causes: causes}}
+	case accepted > 1 && passed == 1:
+		r.moot[exceptionLocation("number_one_of", p.keywords+"/oneOf", pointer)] = true
+	}
+
+	return nil
+}
+
+// not overturns the error gojsonschema reported for the not of p when the
+// formats reject the schema it accepted.
+func (r *formatRun) not(p schemaPath, pointer string, value interface{}) {
+	negated := p.appendChild(nil, p.node["not"], "not")
+	if !r.v.bundle.anyChecked(negated) || !r.valid(negated[0], value) {
+		return
+	}
+
+	if len(r.evaluate(negated[0], pointer, value)) > 0 {
+		r.moot[exceptionLocation("number_not", p.keywords+"/not", pointer)] = true
+	}
+}
+
+// valid reports whether gojsonschema accepts value against the schema p,
+// formats aside.
+func (r *formatRun) valid(p schemaPath, value interface{}) bool {
+	schema, err := r.v.subschemas.compile(p.at.String(), r.v.bundle, p.node)
+	if err != nil {
+		return false
+	}
+
+	result, err := schema.Validate(&documentLoader{document: value})
+
+	return err == nil && result.Valid()
+}
+
+// anyChecked reports whether a stripped format or an extension keyword can
+// be reached from one of paths.
+func (b *schemaBundle) anyChecked(paths []schemaPath) bool {
+	for _, p := range paths {
+		if b.checked[p.at] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// branches returns the branches of the allOf, anyOf or oneOf of p.
+func (p schemaPath) branches(keyword string) []schemaPath {
+	items, _ := p.node[keyword].([]interface{})
+
+	var branches []schemaPath
+	for i, item := range items {
+		branches = p.appendChild(branches, item, keyword, strconv.Itoa(i))
+	}
+
+	return branches
+}
+
+// exceptionLocation identifies an error by its type, keyword location and
+// instance.
+func exceptionLocation(errorType string, keywordLocation string, pointer string) string {
+	return errorType + "\x00" + keywordLocation + "\x00" + pointer
+}
+
+// checkFormats checks the stripped formats of document and returns
+// exceptions, the errors of gojsonschema, without those the formats
+// overturn and with the format failures added.
+func (v *Validator) checkFormats(ctx context.Context, name string, document interface{}, positions *positionIndex, exceptions []ExceptionDetail) []ExceptionDetail {
+	r := v.newFormatRun(ctx, document)
+	failures := r.evaluate(v.bundle.rootPath(), "", document)
+
+	var kept []ExceptionDetail
+	for _, e := range exceptions {
+		if !r.moot[exceptionLocation(e.Type, e.KeywordLocation, e.Pointer)] {
+			kept = append(kept, e)
+		}
+	}
+
+	for _, failure := range failures {
+		kept = append(kept, v.formatException(ctx, name, document, positions, failure))
+	}
+
+	return kept
+}
+
+// formatException describes failure the way exception describes the errors
+// of gojsonschema.
+func (v *Validator) formatException(ctx context.Context, name string, document interface{}, positions *positionIndex, failure formatFailure) ExceptionDetail {
+	if failure.desc != nil {
+		return v.dataException(ctx, name, document, positions, failure)
+	}
+
+	context := instanceContext(failure.pointer)
+	field := failureField(context)
+
+	details := map[string]interface{}{"field": field, "context": context}
+	for key, value := range failure.params {
+		details[key] = value
+	}
+
+	errorType, text := failure.errorType, failure.text
+	switch failure.keyword {
+	case "format":
+		errorType, text = "format", gojsonschema.Locale.DoesNotMatchFormat()
+	case "anyOf":
+		errorType, text = "number_any_of", gojsonschema.Locale.NumberAnyOf()
+	case "oneOf":
+		errorType, text = "number_one_of", gojsonschema.Locale.NumberOneOf()
+	}
+
+	message, ok := localize(v.locale, errorType, details)
+	if !ok {
+		message, _ = renderMessage(errorType, text, details)
+	}
+
+	exception := ExceptionDetail{
+		ErrorString: field + ": " + message,
+		Path:        context,
+		Type:        errorType,
+		Pointer:     failure.pointer,
+		Value:       failure.value,
+	}
+
+	if len(failure.params) > 0 {
+		exception.Params = failure.params
+	}
+
+	if span, ok := positions.lookup(failure.pointer, false); ok {
+		exception.File = name
+		exception.setSpan(span)
+	}
+
+	exception.KeywordLocation, exception.AbsoluteKeywordLocation = failure.owner.keywordLocation(failure.keyword)
+	exception.units = v.bundle.units(exception.KeywordLocation, failure.pointer)
+
+	if !v.causes {
+		return exception
+	}
+
+	for _, cause := range failure.causes {
+		exception.Causes = append(exception.Causes, v.formatException(ctx, name, document, positions, cause))
+	}
+	sortExceptions(exception.Causes)
+
+	return exception
+}
+
+// instanceContext returns the gojsonschema context of the instance at
+// pointer, e.g. "(root).nodes.0".
+func instanceContext(pointer string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("(root)")
+	for _, token := range splitPointer(pointer) {
+		buf.WriteString(".")
+		buf.WriteString(token)
+	}
+
+	return buf.String()
+}
+
+// failureField returns the field gojsonschema names in its messages for the
+// instance of context: the context without its root.
+func failureField(context string) string {
+	return strings.TrimPrefix(context, "(root).")
+}
+
+// typeMatches reports whether value is of the JSON type, or one of the
+// JSON types, of a type keyword. A missing type matches anything.
+func typeMatches(types interface{}, value interface{}) bool {
+	switch t := types.(type) {
+	case string:
+		return jsonType(value) == t || t == "number" && jsonType(value) == "integer"
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && typeMatches(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// jsonType returns the JSON type of a decoded instance, telling integers
+// apart from other numbers.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return ""
+}
@@ -0,0 +1,277 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/blang/semver"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Format is a named format checker, with the description, accepted
+// examples and rejected values listed by the formats command.
+type Format struct {
+	Name        string
+	Description string
+	Examples    []string
+	Rejected    []string
+	Checker     gojsonschema.FormatChecker
+}
+
+// Types returns the JSON types the format applies to: those declared by a
+// TypedFormatChecker, strings otherwise.
+func (f Format) Types() []string {
+	if typed, ok := f.Checker.(TypedFormatChecker); ok {
+		return typed.Types()
+	}
+
+	return []string{"string"}
+}
+
+// applies reports whether value, a decoded instance, is of a type the
+// format applies to. "number" covers integers.
+func (f Format) applies(value interface{}) bool {
+	t := jsonType(value)
+
+	for _, name := range f.Types() {
+		if name == t || name == "number" && t == "integer" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TypedFormatChecker is a format checker declaring the JSON types it
+// applies to, among "string", "number", "integer", "boolean", "null",
+// "array" and "object". As the specification has it, instances of other
+// types are not of the format's concern and are valid; the checker is only
+// called with values of its types, strings as string and numbers as
+// json.Number. Checkers that are not typed are only given strings.
+type TypedFormatChecker interface {
+	gojsonschema.FormatChecker
+	Types() []string
+}
+
+// StringFormat is a TypedFormatChecker applying to strings only, checking
+// them with the function.
+type StringFormat func(string) bool
+
+// IsFormat checks strings with f; other values pass.
+func (f StringFormat) IsFormat(input interface{}) bool {
+	s, ok := input.(string)
+
+	return !ok || f(s)
+}
+
+// Types returns "string".
+func (f StringFormat) Types() []string {
+	return []string{"string"}
+}
+
+// FormatRegistry is a named set of format checkers made available to the
+// "format" keyword of the schemas a Validator compiles. Each Validator
+// checks the formats of its own registry, so Validators with different
+// registries can be used side by side.
+type FormatRegistry struct {
+	mu      sync.RWMutex
+	formats map[string]Format
+}
+
+// NewFormatRegistry returns an empty registry. The built-in formats
+// (date-time, email, uri, ...) are always available in addition to the ones
+// added here; see BuiltinFormats.
+func NewFormatRegistry() *FormatRegistry {
+	return &FormatRegistry{formats: map[string]Format{}}
+}
+
+// DefaultFormats returns a registry holding the custom formats shipped with
+// jsonsvalidator: cidr, semver, the Kubernetes formats (k8s-quantity,
+// dns1123-label, k8s-label-key, docker-image-reference, ...) and the
+// operational ones (duration, cron, port, pem-certificate, ...).
+func DefaultFormats() *FormatRegistry {
+	r := NewFormatRegistry()
+
+	// extend the checker to handle CIDRs
+	r.Register(Format{
+		Name:        "cidr",
+		Description: "an IPv4 or IPv6 network in CIDR notation",
+		Examples:    []string{"10.0.0.0/16", "2001:db8::/32"},
+		Rejected:    []string{"10.0.0.0", "10.0.0.0/33"},
+		Checker:     CIDRFormatChecker{},
+	})
+
+	// extend the checker to handle symver
+	r.Register(Format{
+		Name:        "semver",
+		Description: "a semantic version, see semver.org",
+		Examples:    []string{"1.7.3", "2.0.0-rc.1+build.5"},
+		Rejected:    []string{"1.7", "v1.7.3"},
+		Checker:     SemVerFormatChecker{},
+	})
+
+	for _, format := range append(kubernetesFormats(), operationalFormats()...) {
+		r.Register(format)
+	}
+
+	return r
+}
+
+// Add registers checker under name, replacing any format already
+// registered with that name.
+func (r *FormatRegistry) Add(name string, checker gojsonschema.FormatChecker) *FormatRegistry {
+	return r.Register(Format{Name: name, Checker: checker})
+}
+
+// Register registers format, replacing any format already registered with
+// its name. Registering a built-in format name replaces the built-in
+// checker for the Validators using the registry.
+func (r *FormatRegistry) Register(format Format) *FormatRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.formats[format.Name] = format
+
+	return r
+}
+
+// Names returns the registered format names in sorted order.
+func (r *FormatRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.formats))
+	for name := range r.formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Formats returns the registered formats sorted by name.
+func (r *FormatRegistry) Formats() []Format {
+	var formats []Format
+
+	for _, name := range r.Names() {
+		if format, ok := r.Lookup(name); ok {
+			formats = append(formats, format)
+		}
+	}
+
+	return formats
+}
+
+// Lookup returns the format registered under name.
+func (r *FormatRegistry) Lookup(name string) (Format, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	format, ok := r.formats[name]
+
+	return format, ok
+}
+
+// resolve returns the format registered under name, or else the built-in
+// format of that name.
+func (r *FormatRegistry) resolve(name string) (Format, bool) {
+	if format, ok := r.Lookup(name); ok {
+		return format, true
+	}
+
+	for _, format := range BuiltinFormats() {
+		if format.Name == name {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// check reports whether value, a decoded instance, is of the format named
+// name. Values of types the format does not apply to are.
+func (r *FormatRegistry) check(name string, value interface{}) bool {
+	format, ok := r.resolve(name)
+	if !ok || !format.applies(value) {
+		return true
+	}
+
+	return format.Checker.IsFormat(value)
+}
+
+// BuiltinFormats describes the JSON Schema formats available whatever the
+// registry, checked with gojsonschema's checkers, sorted by name. They apply
+// to strings only.
+func BuiltinFormats() []Format {
+	return []Format{
+		{"date-time", "an RFC 3339 date and time", []string{"2017-06-01T12:00:00Z"}, []string{"06/01/2017"}, gojsonschema.DateTimeFormatChecker{}},
+		{"email", "an email address", []string{"ops@example.com"}, []string{"ops"}, gojsonschema.EmailFormatChecker{}},
+		{"hostname", "an RFC 1123 host name", []string{"node-1.example.com"}, []string{"node_1"}, gojsonschema.HostnameFormatChecker{}},
+		{"ipv4", "an IPv4 address", []string{"10.0.0.1"}, []string{"10.0.0.256"}, gojsonschema.IPV4FormatChecker{}},
+		{"ipv6", "an IPv6 address", []string{"2001:db8::1"}, []string{"10.0.0.1"}, gojsonschema.IPV6FormatChecker{}},
+		{"regex", "a regular expression", []string{"^[a-z]+$"}, []string{"[a-"}, gojsonschema.RegexFormatChecker{}},
+		{"uri", "an absolute URI", []string{"https://example.com/schemas/config.json"}, []string{"schemas/config.json"}, gojsonschema.URIFormatChecker{}},
+		{"uri-reference", "a URI or a relative reference", []string{"../schemas/config.json"}, []string{"%zz"}, gojsonschema.URIReferenceFormatChecker{}},
+		{"uuid", "a lower case UUID", []string{"f81d4fae-7dec-11d0-a765-00a0c91e6bf6"}, []string{"f81d4fae"}, gojsonschema.UUIDFormatChecker{}},
+	}
+}
+
+// CIDRFormatChecker struct to extend gojsonschema FormatCheckers
+type CIDRFormatChecker struct{}
+
+// IsFormat for CIDRFormatChecker - custom format checker for CIDRs
+// extending gojsonschema.FormatChecker
+// https://github.com/xeipuuv/gojsonschema
+func (f CIDRFormatChecker) IsFormat(input interface{}) bool {
+	s, ok := input.(string)
+	if !ok {
+		return true
+	}
+
+	_, _, err := net.ParseCIDR(s)
+
+	return err == nil
+}
+
+// Types returns "string": CIDRs are only checked in strings.
+func (f CIDRFormatChecker) Types() []string {
+	return []string{"string"}
+}
+
+// SemVerFormatChecker struct to extend gojsonschema FormatCheckers
+type SemVerFormatChecker struct{}
+
+// IsFormat for SemVerFormatChecker - custom format checker for semantics version format
+// extending gojsonschema.FormatChecker
+// https://github.com/xeipuuv/gojsonschema
+func (f SemVerFormatChecker) IsFormat(input interface{}) bool {
+	s, ok := input.(string)
+	if !ok {
+		return true
+	}
+
+	_, err := semver.Make(s)
+
+	return err == nil
+}
+
+// Types returns "string": versions written as YAML numbers, such as 1.6,
+// are left to the type keyword.
+func (f SemVerFormatChecker) Types() []string {
+	return []string{"string"}
+}
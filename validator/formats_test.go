@@ -0,0 +1,346 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// evenLength accepts strings of an even length.
+type evenLength struct{}
+
+func (evenLength) IsFormat(input interface{}) bool {
+	s, ok := input.(string)
+	return ok && len(s)%2 == 0
+}
+
+// anything accepts everything.
+type anything struct{}
+
+func (anything) IsFormat(input interface{}) bool {
+	return true
+}
+
+func TestFormatRegistries(t *testing.T) {
+	schema := []byte(`{ "properties": { "id": { "type": "string", "format": "even" } } }`)
+
+	strict, err := New(WithSchemaBytes("even.json", schema), WithFormats(NewFormatRegistry().Add("even", evenLength{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lenient, err := New(WithSchemaBytes("even.json", schema), WithFormats(NewFormatRegistry().Add("even", anything{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gojsonschema.FormatCheckers.Has("even") {
+		t.Error("expected the format to stay out of gojsonschema's global checkers")
+	}
+
+	// both validators check formats side by side
+	var wg sync.WaitGroup
+	errs := make(chan string, 20)
+
+	for i := 0; i < 10; i++ {
+		for _, v := range []*Validator{strict, lenient} {
+			wg.Add(1)
+
+			go func(v *Validator) {
+				defer wg.Done()
+
+				result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("id: abc\n"))
+				if err != nil {
+					errs <- err.Error()
+					return
+				}
+
+				if result.IsValid != (v == lenient) {
+					errs <- fmt.Sprintf("%s: unexpected result %+v", v.SchemaName(), result)
+				}
+			}(v)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if _, err := New(WithSchemaBytes("even.json", schema), WithFormats(NewFormatRegistry())); err == nil {
+		t.Error("expected an unknown format to fail the schema")
+	}
+}
+
+func TestFormatExamples(t *testing.T) {
+	registry := DefaultFormats()
+
+	for _, format := range append(registry.Formats(), BuiltinFormats()...) {
+		if len(format.Examples) == 0 || len(format.Rejected) == 0 {
+			t.Errorf("%s: expected accepted and rejected examples", format.Name)
+		}
+
+		for _, example := range format.Examples {
+			if !registry.check(format.Name, example) {
+				t.Errorf("%s: example %q is rejected", format.Name, example)
+			}
+		}
+		for _, example := range format.Rejected {
+			if registry.check(format.Name, example) {
+				t.Errorf("%s: rejected example %q is accepted", format.Name, example)
+			}
+		}
+	}
+}
+
+func TestFormatApplicators(t *testing.T) {
+	v, err := New(WithSchemaBytes("applicators.json", []byte(`{
+		"properties": {
+			"any":  { "anyOf": [ { "format": "cidr" }, { "format": "semver" } ] },
+			"one":  { "oneOf": [ { "format": "cidr" }, { "type": "string", "format": "semver" } ] },
+			"not":  { "not": { "format": "cidr" } },
+			"all":  { "allOf": [ { "$ref": "#/definitions/cidr" } ] },
+			"list": { "items": { "$ref": "#/definitions/cidr" } },
+			"default": { "type": "string", "format": "cidr" }
+		},
+		"definitions": {
+			"cidr": { "type": "string", "format": "cidr" }
+		}
+	}`)), WithCauses())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		errors   []string
+	}{
+		{`{"any": "1.2.3", "one": "10.0.0.0/8", "not": "nope", "all": "10.0.0.0/8", "list": ["10.0.0.0/8"]}`, nil},
+		{`{"any": "nope"}`, []string{"number_any_of /properties/any/anyOf"}},
+		{`{"one": "nope"}`, []string{"number_one_of /properties/one/oneOf"}},
+		{`{"not": "10.0.0.0/8"}`, []string{"number_not /properties/not/not"}},
+		{`{"all": "nope"}`, []string{"format /properties/all/allOf/0/$ref/format"}},
+		{`{"list": ["10.0.0.0/8", "nope"]}`, []string{"format /properties/list/items/$ref/format"}},
+		{`{"list": [3]}`, []string{"invalid_type /properties/list/items/$ref/type"}},
+		{`{"default": "10.0.0.0/8"}`, nil},
+		{`{"default": "nope"}`, []string{"format /properties/default/format"}},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "config.json", []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: %v", test.document, err)
+			continue
+		}
+
+		var errors []string
+		for _, e := range result.Exceptions {
+			errors = append(errors, e.Type+" "+e.KeywordLocation)
+		}
+
+		if strings.Join(errors, ",") != strings.Join(test.errors, ",") || result.IsValid != (len(test.errors) == 0) {
+			t.Errorf("%s: expected %v, got %+v", test.document, test.errors, result.Exceptions)
+		}
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("any: nope\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := result.Exceptions[0]
+	if e.ErrorString != "any: Must validate at least one schema (anyOf)" || e.Line != 1 || e.Column != 6 || len(e.Causes) != 2 {
+		t.Errorf("expected an anyOf error at 1:6 with two causes, got %+v", e)
+	}
+
+	if cause := e.Causes[0]; cause.ErrorString != "any: Does not match format 'cidr'" || cause.KeywordLocation != "/properties/any/anyOf/0/format" {
+		t.Errorf("expected the cidr branch to fail its format, got %+v", cause)
+	}
+}
+
+// randomValue returns an arbitrary decoded instance, nested up to depth
+// levels, mixing the values the decoders produce with odd ones.
+func randomValue(r *rand.Rand, depth int) interface{} {
+	n := 8
+	if depth > 0 {
+		n = 10
+	}
+
+	switch r.Intn(n) {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return json.Number(strconv.Itoa(r.Intn(2000) - 1000))
+	case 3:
+		return json.Number(strconv.FormatFloat(r.NormFloat64()*1e3, 'g', -1, 64))
+	case 4:
+		words := []string{"", "10.0.0.0/16", "1.7.3", "v1.2", "::1", "2001:db8::/32", "10", "1.6", "a b", "\x00", "%zz", "é"}
+		return words[r.Intn(len(words))]
+	case 5:
+		b := make([]byte, r.Intn(16))
+		r.Read(b)
+		return string(b)
+	case 6:
+		return r.Float64()
+	case 7:
+		return r.Int()
+	case 8:
+		list := make([]interface{}, r.Intn(3))
+		for i := range list {
+			list[i] = randomValue(r, depth-1)
+		}
+		return list
+	default:
+		object := map[string]interface{}{}
+		for i := r.Intn(3); i > 0; i-- {
+			object[strconv.Itoa(i)] = randomValue(r, depth-1)
+		}
+		return object
+	}
+}
+
+func TestFormatsNeverPanic(t *testing.T) {
+	registry := DefaultFormats()
+	formats := append(registry.Formats(), BuiltinFormats()...)
+	r := rand.New(rand.NewSource(1))
+
+	for _, format := range formats {
+		schema := []byte(`{"items": {"format": "` + format.Name + `"}}`)
+		v, err := New(WithSchemaBytes(format.Name+".json", schema), WithFormats(registry))
+		if err != nil {
+			t.Fatalf("%s: %v", format.Name, err)
+		}
+
+		for i := 0; i < 200; i++ {
+			value := randomValue(r, 2)
+
+			func() {
+				defer func() {
+					if p := recover(); p != nil {
+						t.Errorf("%s: checking %#v panicked: %v", format.Name, value, p)
+					}
+				}()
+
+				format.Checker.IsFormat(value)
+
+				if !format.applies(value) && !registry.check(format.Name, value) {
+					t.Errorf("%s: expected %#v to pass, the format does not apply to it", format.Name, value)
+				}
+			}()
+		}
+
+		var list []interface{}
+		for i := 0; i < 50; i++ {
+			list = append(list, randomValue(r, 2))
+		}
+
+		data, err := json.Marshal(list)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		document, err := decodeJSON(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := v.ValidateBytes(context.Background(), "fuzz.json", data)
+		if err != nil {
+			t.Fatalf("%s: %v", format.Name, err)
+		}
+
+		for _, exception := range result.Exceptions {
+			if value := document.([]interface{})[indexOf(exception.Pointer)]; !format.applies(value) {
+				t.Errorf("%s: expected only values of %v to fail, got %s", format.Name, format.Types(), exception.ErrorString)
+			}
+		}
+	}
+
+	// YAML numbers where strings were meant are left to the type keyword
+	v, err := New(WithSchemaBytes("numbers.json", []byte(`{
+		"properties": {
+			"network": { "format": "cidr" },
+			"version": { "format": "semver" },
+			"address": { "format": "ipv4" }
+		}
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("network: 10\nversion: 1.6\naddress: 10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsValid {
+		t.Errorf("expected numbers to pass string formats, got %+v", result.Exceptions)
+	}
+}
+
+// indexOf returns the array index a /n pointer names.
+func indexOf(pointer string) int {
+	i, _ := strconv.Atoi(strings.TrimPrefix(pointer, "/"))
+	return i
+}
+
+func TestFormatsRecursive(t *testing.T) {
+	v, err := New(WithSchemaBytes("tree.json", []byte(`{
+		"type": "object",
+		"properties": {
+			"cidr": { "type": "string", "format": "cidr" },
+			"version": { "type": "string", "x-semver-range": ">=1.0.0" },
+			"min": { "type": "integer" },
+			"max": { "type": "integer", "minimum": { "$data": "1/min" } },
+			"children": { "type": "array", "items": { "$ref": "#" } }
+		}
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := `{"cidr": "10.0.0.0/8", "children": [{"children": [{"cidr": "notacidr", "version": "0.1.0", "min": 5, "max": 3}]}]}`
+
+	result, err := v.ValidateBytes(context.Background(), "config.json", []byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errors []string
+	for _, e := range result.Exceptions {
+		errors = append(errors, e.Type+" "+e.Pointer)
+	}
+
+	want := []string{
+		"format /children/0/children/0/cidr",
+		"semver_range /children/0/children/0/version",
+		"number_gte /children/0/children/0/max",
+	}
+	if strings.Join(errors, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v at every level of the recursion, got %+v", want, result.Exceptions)
+	}
+}
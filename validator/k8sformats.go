@@ -0,0 +1,211 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// The Kubernetes formats follow the validation rules of
+// k8s.io/apimachinery (resource quantities, DNS names, label keys, values
+// and selectors) and of github.com/docker/distribution (image references).
+
+const (
+	dns1123LabelMaxLength     = 63
+	dns1123SubdomainMaxLength = 253
+	qualifiedNameMaxLength    = 63
+	labelValueMaxLength       = 63
+	imageNameMaxLength        = 255
+)
+
+var (
+	quantityRegexp         = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+|[KMGTPE]i|[numkMGTPE])?$`)
+	dns1123LabelRegexp     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dns1123SubdomainRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	qualifiedNameRegexp    = regexp.MustCompile(`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`)
+	setRequirementRegexp   = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+	imageReferenceRegexp   = regexp.MustCompile(`^(` +
+		// domain, with an optional port
+		`(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+		// path components
+		`([a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*)*)` +
+		// tag
+		`(?::[\w][\w.-]{0,127})?` +
+		// digest
+		`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+)
+
+// QuantityFormatChecker checks Kubernetes resource quantities, such as
+// 500Mi, 0.5 or 1e3. Quantities written as numbers are checked too.
+type QuantityFormatChecker struct{}
+
+// IsFormat reports whether input is a resource quantity.
+func (f QuantityFormatChecker) IsFormat(input interface{}) bool {
+	switch v := input.(type) {
+	case string:
+		return quantityRegexp.MatchString(v)
+	case json.Number:
+		return quantityRegexp.MatchString(v.String())
+	}
+
+	return true
+}
+
+// Types returns "string" and "number".
+func (f QuantityFormatChecker) Types() []string {
+	return []string{"string", "number"}
+}
+
+// isDNS1123Label reports whether s is a DNS label as defined by RFC 1123,
+// as used for most Kubernetes object names.
+func isDNS1123Label(s string) bool {
+	return len(s) <= dns1123LabelMaxLength && dns1123LabelRegexp.MatchString(s)
+}
+
+// isDNS1123Subdomain reports whether s is a DNS subdomain as defined by
+// RFC 1123.
+func isDNS1123Subdomain(s string) bool {
+	return len(s) <= dns1123SubdomainMaxLength && dns1123SubdomainRegexp.MatchString(s)
+}
+
+// isLabelKey reports whether s is a label key: a name, optionally prefixed
+// by a DNS subdomain and a slash.
+func isLabelKey(s string) bool {
+	name := s
+	if i := strings.Index(s, "/"); i >= 0 {
+		if !isDNS1123Subdomain(s[:i]) {
+			return false
+		}
+		name = s[i+1:]
+	}
+
+	return len(name) <= qualifiedNameMaxLength && qualifiedNameRegexp.MatchString(name)
+}
+
+// isLabelValue reports whether s is a label value, which may be empty.
+func isLabelValue(s string) bool {
+	return s == "" || len(s) <= labelValueMaxLength && qualifiedNameRegexp.MatchString(s)
+}
+
+// isLabelSelector reports whether s is a label selector in the string form
+// kubectl's --selector takes: comma separated requirements, each one of
+// key, !key, key=value, key==value, key!=value, key in (values),
+// key notin (values), key>n or key<n. The empty selector selects
+// everything.
+func isLabelSelector(s string) bool {
+	if strings.TrimSpace(s) == "" {
+		return true
+	}
+
+	for _, requirement := range splitRequirements(s) {
+		if !isRequirement(strings.TrimSpace(requirement)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitRequirements splits a selector at the commas outside parentheses.
+func splitRequirements(s string) []string {
+	var requirements []string
+
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				requirements = append(requirements, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(requirements, s[start:])
+}
+
+func isRequirement(s string) bool {
+	if m := setRequirementRegexp.FindStringSubmatch(s); m != nil {
+		if !isLabelKey(m[1]) || strings.TrimSpace(m[3]) == "" {
+			return false
+		}
+		for _, value := range strings.Split(m[3], ",") {
+			if !isLabelValue(strings.TrimSpace(value)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if strings.HasPrefix(s, "!") {
+		return isLabelKey(strings.TrimSpace(s[1:]))
+	}
+
+	for _, operator := range []string{"==", "!=", "=", ">", "<"} {
+		if i := strings.Index(s, operator); i >= 0 {
+			key, value := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len(operator):])
+			if operator == ">" || operator == "<" {
+				_, err := strconv.ParseInt(value, 10, 64)
+				return isLabelKey(key) && err == nil
+			}
+			return isLabelKey(key) && isLabelValue(value)
+		}
+	}
+
+	return isLabelKey(s)
+}
+
+// isImageReference reports whether s is a container image reference,
+// [registry/]repository[:tag][@digest].
+func isImageReference(s string) bool {
+	m := imageReferenceRegexp.FindStringSubmatch(s)
+
+	return m != nil && len(m[1])+len(m[2]) <= imageNameMaxLength
+}
+
+// isKubernetesVersion reports whether s is a Kubernetes release version: v
+// followed by a semantic version, as in v1.7.3 or v1.8.0-beta.1.
+func isKubernetesVersion(s string) bool {
+	if !strings.HasPrefix(s, "v") {
+		return false
+	}
+
+	_, err := semver.Make(s[1:])
+
+	return err == nil
+}
+
+// kubernetesFormats are the Kubernetes formats of DefaultFormats.
+func kubernetesFormats() []Format {
+	return []Format{
+		{"k8s-quantity", "a Kubernetes resource quantity", []string{"500Mi", "0.5", "100m"}, []string{"500MB", "Mi"}, QuantityFormatChecker{}},
+		{"dns1123-label", "an RFC 1123 DNS label of at most 63 characters, as used for object names", []string{"kube-system"}, []string{"Kube_System", "-kube"}, StringFormat(isDNS1123Label)},
+		{"dns1123-subdomain", "an RFC 1123 DNS subdomain of at most 253 characters", []string{"apps.example.com"}, []string{"apps..example.com"}, StringFormat(isDNS1123Subdomain)},
+		{"k8s-label-key", "a label or annotation key, optionally prefixed by a DNS subdomain", []string{"app", "app.kubernetes.io/name"}, []string{"example.com/", "_app"}, StringFormat(isLabelKey)},
+		{"k8s-label-value", "a label value, possibly empty", []string{"nginx", "v1.2_beta"}, []string{"a/b", "-nginx"}, StringFormat(isLabelValue)},
+		{"label-selector", "a label selector, as given to kubectl --selector", []string{"app=nginx,tier!=cache", "env in (prod, staging)"}, []string{"env in ()", "app=nginx,"}, StringFormat(isLabelSelector)},
+		{"docker-image-reference", "a container image reference, [registry/]repository[:tag][@digest]", []string{"nginx:1.13", "quay.io/samsung_cnct/kraken:v1.0"}, []string{"Nginx", "nginx:"}, StringFormat(isImageReference)},
+		{"k8s-version", "a Kubernetes release version", []string{"v1.7.3", "v1.8.0-beta.1"}, []string{"1.7.3", "v1.7"}, StringFormat(isKubernetesVersion)},
+	}
+}
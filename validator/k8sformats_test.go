@@ -0,0 +1,125 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestKubernetesFormats(t *testing.T) {
+	registry := DefaultFormats()
+
+	tests := []struct {
+		format string
+		value  interface{}
+		valid  bool
+	}{
+		{"k8s-quantity", "500Mi", true},
+		{"k8s-quantity", "0.5", true},
+		{"k8s-quantity", "100m", true},
+		{"k8s-quantity", "1e3", true},
+		{"k8s-quantity", "-2Gi", true},
+		{"k8s-quantity", ".5k", true},
+		{"k8s-quantity", json.Number("0.5"), true},
+		{"k8s-quantity", json.Number("2"), true},
+		{"k8s-quantity", "500MB", false},
+		{"k8s-quantity", "Mi", false},
+		{"k8s-quantity", "1.2.3", false},
+		{"k8s-quantity", "5 Mi", false},
+		{"k8s-quantity", "", false},
+
+		{"dns1123-label", "kube-system", true},
+		{"dns1123-label", "a", true},
+		{"dns1123-label", "0abc", true},
+		{"dns1123-label", strings.Repeat("a", 63), true},
+		{"dns1123-label", strings.Repeat("a", 64), false},
+		{"dns1123-label", "Kube", false},
+		{"dns1123-label", "-kube", false},
+		{"dns1123-label", "kube-", false},
+		{"dns1123-label", "kube.system", false},
+		{"dns1123-label", "", false},
+
+		{"dns1123-subdomain", "apps.example.com", true},
+		{"dns1123-subdomain", "example", true},
+		{"dns1123-subdomain", strings.Repeat("a.", 126) + "a", true},
+		{"dns1123-subdomain", strings.Repeat("a.", 127) + "a", false},
+		{"dns1123-subdomain", "apps..example", false},
+		{"dns1123-subdomain", ".example", false},
+		{"dns1123-subdomain", "Example.com", false},
+
+		{"k8s-label-key", "app", true},
+		{"k8s-label-key", "app.kubernetes.io/name", true},
+		{"k8s-label-key", "Node_Role.1", true},
+		{"k8s-label-key", strings.Repeat("a", 63), true},
+		{"k8s-label-key", strings.Repeat("a", 64), false},
+		{"k8s-label-key", "example.com/", false},
+		{"k8s-label-key", "/name", false},
+		{"k8s-label-key", "Example.com/name", false},
+		{"k8s-label-key", "a/b/c", false},
+		{"k8s-label-key", "_app", false},
+		{"k8s-label-key", "", false},
+
+		{"k8s-label-value", "nginx", true},
+		{"k8s-label-value", "v1.2_beta", true},
+		{"k8s-label-value", "", true},
+		{"k8s-label-value", strings.Repeat("a", 64), false},
+		{"k8s-label-value", "a/b", false},
+		{"k8s-label-value", "-a", false},
+
+		{"label-selector", "app=nginx,tier!=cache", true},
+		{"label-selector", "env in (prod, staging)", true},
+		{"label-selector", "env notin (dev),!canary", true},
+		{"label-selector", "app.kubernetes.io/name==web", true},
+		{"label-selector", "partition, replicas>2", true},
+		{"label-selector", "app=", true},
+		{"label-selector", "", true},
+		{"label-selector", "env in ()", false},
+		{"label-selector", "env in (prod", false},
+		{"label-selector", "app=nginx,", false},
+		{"label-selector", "replicas>two", false},
+		{"label-selector", "app=ng inx", false},
+		{"label-selector", "!", false},
+
+		{"docker-image-reference", "nginx", true},
+		{"docker-image-reference", "nginx:1.13", true},
+		{"docker-image-reference", "library/nginx:latest", true},
+		{"docker-image-reference", "quay.io/samsung_cnct/kraken:v1.0", true},
+		{"docker-image-reference", "localhost:5000/team/app", true},
+		{"docker-image-reference", "nginx@sha256:" + strings.Repeat("a", 64), true},
+		{"docker-image-reference", "gcr.io/google-containers/pause:3.0@sha256:" + strings.Repeat("0", 64), true},
+		{"docker-image-reference", "Nginx", false},
+		{"docker-image-reference", "nginx:", false},
+		{"docker-image-reference", "nginx:-tag", false},
+		{"docker-image-reference", "nginx@sha256:abc", false},
+		{"docker-image-reference", "quay.io/", false},
+		{"docker-image-reference", "a/" + strings.Repeat("b", 254), false},
+
+		{"k8s-version", "v1.7.3", true},
+		{"k8s-version", "v1.8.0-beta.1", true},
+		{"k8s-version", "v1.7.3+coreos.0", true},
+		{"k8s-version", "1.7.3", false},
+		{"k8s-version", "v1.7", false},
+		{"k8s-version", "v", false},
+	}
+
+	for _, test := range tests {
+		if valid := registry.check(test.format, test.value); valid != test.valid {
+			t.Errorf("%s: expected %#v to be valid %v, got %v", test.format, test.value, test.valid, valid)
+		}
+	}
+
+}
@@ -0,0 +1,167 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHTTPTimeout bounds each HTTP(S) request made to fetch a schema.
+	DefaultHTTPTimeout = 30 * time.Second
+
+	// DefaultMaxSchemaSize is the largest schema document, in bytes, that
+	// will be read from a file or an HTTP(S) response.
+	DefaultMaxSchemaSize = 10 << 20
+)
+
+// schemaFetcher loads schema documents from local files and HTTP(S) URLs.
+// The root schema and every document reached through its $refs are fetched
+// through it, so they share its limits and headers.
+type schemaFetcher struct {
+	client  *http.Client
+	maxSize int64
+	headers http.Header
+
+	// headerHost is the host the extra headers may be sent to: the host
+	// of the root schema. Referenced schemas on other hosts never see them.
+	headerHost string
+}
+
+// fetch returns the raw bytes of the document at uri, which must be a
+// file:// or an http(s):// URI.
+func (f *schemaFetcher) fetch(uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "file":
+		return f.fetchFile(u.Path)
+	case "http", "https":
+		return f.fetchHTTP(u)
+	default:
+		return nil, fmt.Errorf("unsupported schema location %q; expected a file path or an http(s) URL", uri)
+	}
+}
+
+func (f *schemaFetcher) fetchFile(path string) ([]byte, error) {
+	if _, err := fileExists(path); err != nil {
+		return nil, fmt.Errorf("reading schema %s: %v", path, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema %s: %v", path, err)
+	}
+	defer file.Close()
+
+	data, err := readLimited(file, f.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema %s: %v", path, err)
+	}
+
+	return data, nil
+}
+
+func (f *schemaFetcher) fetchHTTP(u *url.URL) ([]byte, error) {
+	u.Fragment = ""
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/schema+json, application/json;q=0.9, application/yaml;q=0.8, */*;q=0.1")
+	if u.Host == f.headerHost {
+		for name, values := range f.headers {
+			req.Header[name] = values
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching schema %s: %v", u, err)
+	}
+	defer resp.Body.Close()
+
+	// must return HTTP Status 200 OK
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching schema %s: unexpected HTTP status %s", u, resp.Status)
+	}
+
+	if f.maxSize > 0 && resp.ContentLength > f.maxSize {
+		return nil, fmt.Errorf("fetching schema %s: document exceeds the maximum size of %d bytes", u, f.maxSize)
+	}
+
+	data, err := readLimited(resp.Body, f.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetching schema %s: %v", u, err)
+	}
+
+	return data, nil
+}
+
+// load fetches and decodes the schema document at uri, returning the index
+// of its source positions along with it.
+func (f *schemaFetcher) load(uri string) (interface{}, *positionIndex, error) {
+	data, err := f.fetch(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return decodeSchema("schema", uri, data)
+}
+
+// decodeSchema decodes a YAML or JSON schema document into the value tree
+// gojsonschema decodes JSON into, with numbers kept as json.Number. Schemas
+// go through the same normalization as configs, so they may be written in
+// YAML, comments included. kind names the document in errors, such as
+// "schema" or "rules".
+func decodeSchema(kind string, uri string, data []byte) (interface{}, *positionIndex, error) {
+	if documents := splitDocuments(data); len(documents) > 1 {
+		return nil, nil, fmt.Errorf("decoding %s %s: found %d YAML documents; the %s must be a single document", kind, uri, len(documents), kind)
+	}
+
+	var document interface{}
+	var err error
+
+	// the whole of data is decoded, rather than the document splitDocuments
+	// found, so the lines of YAML errors are those of the file
+	if isJSON(data) {
+		document, err = decodeJSON(data)
+	} else {
+		document, err = decodeYAML(data)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding %s %s: %v", kind, uri, err)
+	}
+
+	return document, newPositionIndex(data), nil
+}
+
+// isURL reports whether location is an http:// or https:// URL rather than
+// a file path.
+func isURL(location string) bool {
+	lower := strings.ToLower(location)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
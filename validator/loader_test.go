@@ -0,0 +1,178 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSchemaServer serves a root schema referencing a definitions document,
+// the way the Kraken schemas are published. Requests to /private/ need the
+// "Bearer secret" Authorization header, /slow/ never answers in time.
+func newSchemaServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/config.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+		  "$schema": "http://json-schema.org/draft-04/schema#",
+		  "properties": { "cidr": { "$ref": "definitions.json#/definitions/cidr" } },
+		  "required": [ "cidr" ],
+		  "type": "object"
+		}`))
+	})
+
+	mux.HandleFunc("/v1/definitions.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "definitions": { "cidr": { "type": "string", "format": "cidr" } } }`))
+	})
+
+	mux.HandleFunc("/private/config.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "denied", http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{ "type": "object" }`))
+	})
+
+	mux.HandleFunc("/large/config.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "description": "` + strings.Repeat("x", 1024) + `" }`))
+	})
+
+	mux.HandleFunc("/slow/config.json", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Write([]byte(`{ "type": "object" }`))
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestRemoteSchema(t *testing.T) {
+	server := newSchemaServer(t)
+	defer server.Close()
+
+	v, err := New(WithSchema(server.URL + "/v1/config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.SchemaName() != server.URL+"/v1/config.json" {
+		t.Errorf("unexpected schema name %q", v.SchemaName())
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "valid", []byte("cidr: 10.0.0.0/16\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.IsValid {
+		t.Errorf("expected a valid result, got %+v", result.Exceptions)
+	}
+
+	// the referenced definitions.json is fetched from the same server
+	result, err = v.ValidateBytes(context.Background(), "invalid", []byte("cidr: 10.0.0.0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.IsValid || len(result.Exceptions) != 1 {
+		t.Errorf("expected a single format exception, got %+v", result.Exceptions)
+	}
+}
+
+func TestRemoteSchemaErrors(t *testing.T) {
+	server := newSchemaServer(t)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		expect string
+	}{
+		{
+			"not found",
+			[]Option{WithSchemaURL(server.URL + "/v1/missing.json")},
+			"unexpected HTTP status 404 Not Found",
+		},
+		{
+			"missing credentials",
+			[]Option{WithSchemaURL(server.URL + "/private/config.json")},
+			"unexpected HTTP status 401 Unauthorized",
+		},
+		{
+			"too large",
+			[]Option{WithSchemaURL(server.URL + "/large/config.json"), WithMaxSchemaSize(512)},
+			"exceeds the maximum size of 512 bytes",
+		},
+		{
+			"timeout",
+			[]Option{WithSchemaURL(server.URL + "/slow/config.json"), WithHTTPTimeout(50 * time.Millisecond)},
+			"Client.Timeout exceeded",
+		},
+		{
+			"not a URL",
+			[]Option{WithSchemaURL("ftp://example.com/schema.json")},
+			"expected an absolute http(s) URL",
+		},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.opts...)
+		if err == nil || !strings.Contains(err.Error(), test.expect) {
+			t.Errorf("%s: expected an error containing %q, got %v", test.name, test.expect, err)
+		}
+	}
+}
+
+func TestRemoteSchemaHeaders(t *testing.T) {
+	server := newSchemaServer(t)
+	defer server.Close()
+
+	_, err := New(
+		WithSchemaURL(server.URL+"/private/config.json"),
+		WithHTTPHeader("Authorization", "Bearer secret"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHeadersOnlySentToSchemaHost(t *testing.T) {
+	var leaked string
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = r.Header.Get("Authorization")
+		w.Write([]byte(`{ "type": "string" }`))
+	}))
+	defer other.Close()
+
+	root := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "properties": { "name": { "$ref": "` + other.URL + `/name.json" } } }`))
+	}))
+	defer root.Close()
+
+	_, err := New(WithSchemaURL(root.URL+"/config.json"), WithHTTPHeader("Authorization", "Bearer secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if leaked != "" {
+		t.Errorf("Authorization header leaked to %s", other.URL)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"text/template"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Locale supplies the message templates used to describe validation errors.
+// Templates are keyed by gojsonschema error type ("required", "enum",
+// "format", ...) and use text/template syntax over the error details, just
+// like gojsonschema's own locale. Returning "" keeps gojsonschema's message.
+type Locale interface {
+	Message(errorType string) string
+}
+
+// LocaleMap is a Locale backed by a map of error type to message template.
+type LocaleMap map[string]string
+
+// Message returns the template registered for errorType, if any.
+func (l LocaleMap) Message(errorType string) string {
+	return l[errorType]
+}
+
+// describe renders a gojsonschema error the way gojsonschema's String() does,
+// "field: description", using the validator's locale when it has a template
+// for the error type.
+func describe(locale Locale, desc gojsonschema.ResultError) string {
+	if text, ok := localize(locale, desc.Type(), desc.Details()); ok {
+		return desc.Field() + ": " + text
+	}
+
+	return desc.String()
+}
+
+// localize renders the template locale has for errorType over details. It
+// returns false when there is no usable template.
+func localize(locale Locale, errorType string, details map[string]interface{}) (string, bool) {
+	if locale == nil {
+		return "", false
+	}
+
+	text := locale.Message(errorType)
+	if text == "" {
+		return "", false
+	}
+
+	return renderMessage(errorType, text, details)
+}
+
+// renderMessage renders the message template text over details.
+func renderMessage(name string, text string, details map[string]interface{}) (string, bool) {
+	tpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", false
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, details); err != nil {
+		return "", false
+	}
+
+	return buf.String(), true
+}
+
+// errorParams returns the keyword parameters of a gojsonschema error: its
+// details, without the field and context gojsonschema adds to every error.
+// Bounds gojsonschema formatted as strings are turned back into numbers.
+func errorParams(desc gojsonschema.ResultError) map[string]interface{} {
+	params := map[string]interface{}{}
+
+	for name, value := range desc.Details() {
+		switch name {
+		case "field", "context":
+			continue
+		case "min", "max":
+			if s, ok := value.(string); ok {
+				if _, err := strconv.ParseFloat(s, 64); err == nil {
+					value = json.Number(s)
+				}
+			}
+		}
+
+		params[name] = value
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	return params
+}
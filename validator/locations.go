@@ -0,0 +1,308 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// schemaPath is a schema object reached from the root schema while walking
+// down an instance.
+type schemaPath struct {
+	// at is where the object is written.
+	at location
+
+	// keywords is the path of keywords followed from the root schema, $refs
+	// included, as a JSON Pointer.
+	keywords string
+
+	node map[string]interface{}
+}
+
+// keywordOf maps gojsonschema error types to the keyword raising them.
+var keywordOf = map[string]string{
+	"required":                        "required",
+	"invalid_type":                    "type",
+	"number_any_of":                   "anyOf",
+	"number_one_of":                   "oneOf",
+	"number_all_of":                   "allOf",
+	"number_not":                      "not",
+	"missing_dependency":              "dependencies",
+	"enum":                            "enum",
+	"array_no_additional_items":       "additionalItems",
+	"array_min_items":                 "minItems",
+	"array_max_items":                 "maxItems",
+	"unique":                          "uniqueItems",
+	"array_min_properties":            "minProperties",
+	"array_max_properties":            "maxProperties",
+	"additional_property_not_allowed": "additionalProperties",
+	"invalid_property_pattern":        "patternProperties",
+	"string_gte":                      "minLength",
+	"string_lte":                      "maxLength",
+	"pattern":                         "pattern",
+	"format":                          "format",
+	"multiple_of":                     "multipleOf",
+	"number_gte":                      "minimum",
+	"number_gt":                       "minimum",
+	"number_lte":                      "maximum",
+	"number_lt":                       "maximum",
+}
+
+// keywordLocation returns the schema keyword that raised desc, about the
+// instance at pointer: both as the path of keywords followed from the root
+// schema and as the absolute URI of the keyword. gojsonschema does not keep
+// track of it, so the schema is walked again along the instance pointer; when
+// several schemas apply, as with anyOf, the first one holding the keyword
+// wins.
+func (b *schemaBundle) keywordLocation(pointer string, desc gojsonschema.ResultError) (string, string, bool) {
+	owner, keyword, ok := b.keywordOwner(b.rootPath(), pointer, desc)
+	if !ok {
+		return "", "", false
+	}
+
+	relative, absolute := owner.keywordLocation(keyword)
+
+	return relative, absolute, true
+}
+
+// keywordOwner returns the schema object holding the keyword that raised
+// desc, and the keyword, walking down from the schema at start along the
+// instance pointer, relative to the instance start applies to.
+func (b *schemaBundle) keywordOwner(start schemaPath, pointer string, desc gojsonschema.ResultError) (schemaPath, string, bool) {
+	keyword, ok := keywordOf[desc.Type()]
+	if !ok {
+		return schemaPath{}, "", false
+	}
+
+	var owner *schemaPath
+
+	for _, p := range b.applicable(start, pointer) {
+		value, ok := p.node[keyword]
+		if !ok {
+			continue
+		}
+
+		raised := raisedBy(keyword, value, desc.Details())
+		if owner == nil || raised {
+			found := p
+			owner = &found
+		}
+
+		if raised {
+			break
+		}
+	}
+
+	if owner == nil {
+		return schemaPath{}, "", false
+	}
+
+	return *owner, keyword, true
+}
+
+// keywordLocation returns the location of keyword in p, as a path of
+// keywords and as an absolute URI.
+func (p schemaPath) keywordLocation(keyword string) (string, string) {
+	absolute := location{document: p.at.document, pointer: appendPointer(p.at.pointer, keyword)}
+
+	return appendPointer(p.keywords, keyword), absolute.String()
+}
+
+// rootPath returns the root schema of the bundle.
+func (b *schemaBundle) rootPath() schemaPath {
+	root, _ := b.documents[b.root].(map[string]interface{})
+
+	return schemaPath{at: location{document: b.root}, node: root}
+}
+
+// raisedBy tells whether a keyword holding value can have raised an error
+// with details, for the keywords whose details tell schemas apart.
+func raisedBy(keyword string, value interface{}, details gojsonschema.ErrorDetails) bool {
+	switch keyword {
+	case "required":
+		list, _ := value.([]interface{})
+		for _, property := range list {
+			if property == details["property"] {
+				return true
+			}
+		}
+		return false
+
+	case "format", "pattern":
+		return value == details[keyword]
+
+	case "additionalProperties":
+		return value == false
+	}
+
+	return true
+}
+
+// applicable returns the schema objects applying to the instance at pointer,
+// in the order they are met walking down from the schema at start.
+func (b *schemaBundle) applicable(start schemaPath, pointer string) []schemaPath {
+	paths := b.expand(start, nil)
+
+	for _, token := range splitPointer(pointer) {
+		var children []schemaPath
+		for _, p := range paths {
+			for _, child := range b.children(p, token) {
+				children = b.expand(child, children)
+			}
+		}
+		paths = children
+	}
+
+	return paths
+}
+
+// children returns the subschemas p applies to its member or item token.
+// The instance is not at hand, so a numeric token is tried both as an
+// object member and as an array item.
+func (b *schemaBundle) children(p schemaPath, token string) []schemaPath {
+	children := b.memberSchemas(p, token)
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return children
+	}
+
+	return append(children, b.itemSchemas(p, index)...)
+}
+
+// memberSchemas returns the subschemas p applies to its object member name.
+func (b *schemaBundle) memberSchemas(p schemaPath, name string) []schemaPath {
+	var children []schemaPath
+
+	matched := false
+
+	if properties, ok := p.node["properties"].(map[string]interface{}); ok {
+		if property, ok := properties[name]; ok {
+			children = p.appendChild(children, property, "properties", name)
+			matched = true
+		}
+	}
+
+	if patterns, ok := p.node["patternProperties"].(map[string]interface{}); ok {
+		for pattern, property := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				children = p.appendChild(children, property, "patternProperties", pattern)
+				matched = true
+			}
+		}
+	}
+
+	if !matched {
+		children = p.appendChild(children, p.node["additionalProperties"], "additionalProperties")
+	}
+
+	return children
+}
+
+// itemSchemas returns the subschemas p applies to its array item index.
+func (b *schemaBundle) itemSchemas(p schemaPath, index int) []schemaPath {
+	switch items := p.node["items"].(type) {
+	case map[string]interface{}:
+		return p.appendChild(nil, items, "items")
+	case []interface{}:
+		if index < len(items) {
+			return p.appendChild(nil, items[index], "items", strconv.Itoa(index))
+		}
+		return p.appendChild(nil, p.node["additionalItems"], "additionalItems")
+	}
+
+	return nil
+}
+
+// appendChild appends to children the schema node, found in p under
+// keywords, unless node is not a schema object.
+func (p schemaPath) appendChild(children []schemaPath, node interface{}, keywords ...string) []schemaPath {
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return children
+	}
+
+	next := schemaPath{at: p.at, keywords: p.keywords, node: object}
+	for _, keyword := range keywords {
+		next.at.pointer = appendPointer(next.at.pointer, keyword)
+		next.keywords = appendPointer(next.keywords, keyword)
+	}
+
+	return append(children, next)
+}
+
+// expand appends p to paths, followed by the schemas p pulls in for the
+// same instance: its $ref target and its allOf, anyOf and oneOf branches.
+func (b *schemaBundle) expand(p schemaPath, paths []schemaPath) []schemaPath {
+	for _, seen := range paths {
+		if seen.at == p.at {
+			return paths
+		}
+	}
+
+	paths = append(paths, p)
+
+	if _, ok := p.node["$ref"]; ok {
+		if target, ok := b.refTarget(p); ok {
+			paths = b.expand(target, paths)
+		}
+
+		// draft 4 ignores the siblings of a $ref
+		return paths
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		branches, _ := p.node[keyword].([]interface{})
+
+		for i, branch := range branches {
+			object, ok := branch.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			token := strconv.Itoa(i)
+			next := schemaPath{
+				at:       location{document: p.at.document, pointer: appendPointer(appendPointer(p.at.pointer, keyword), token)},
+				keywords: appendPointer(appendPointer(p.keywords, keyword), token),
+				node:     object,
+			}
+			paths = b.expand(next, paths)
+		}
+	}
+
+	return paths
+}
+
+// refTarget returns the schema the $ref of p points at.
+func (b *schemaBundle) refTarget(p schemaPath) (schemaPath, bool) {
+	ref, _ := p.node["$ref"].(string)
+
+	target, ok := b.internal[ref]
+	if !ok {
+		return schemaPath{}, false
+	}
+
+	node, _ := resolvePointer(b.documents[target.document], target.pointer)
+
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return schemaPath{}, false
+	}
+
+	return schemaPath{at: target, keywords: appendPointer(p.keywords, "$ref"), node: object}, true
+}
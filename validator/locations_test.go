@@ -0,0 +1,103 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeywordLocations(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"root.json": `{
+			"definitions": {
+				"port": {"type": "integer", "minimum": 1, "maximum": 65535}
+			},
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string"},
+				"nodes": {
+					"type": "array",
+					"items": {"$ref": "node.json"}
+				},
+				"port": {"$ref": "#/definitions/port"},
+				"endpoint": {
+					"anyOf": [
+						{"required": ["host"]},
+						{"required": ["url"]}
+					]
+				}
+			}
+		}`,
+		"node.json": `{
+			"additionalProperties": false,
+			"properties": {
+				"name": {"type": "string", "minLength": 3}
+			}
+		}`,
+	})
+
+	v, err := New(WithSchemaFile(filepath.Join(dir, "root.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	document := `
+nodes:
+- name: ab
+  extra: 1
+port: 0
+endpoint: {}
+`
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := "file://" + filepath.ToSlash(dir)
+
+	expected := map[string][2]string{
+		"required ":                {"/required", root + "/root.json#/required"},
+		"required /endpoint":       {"/properties/endpoint/anyOf/0/required", root + "/root.json#/properties/endpoint/anyOf/0/required"},
+		"string_gte /nodes/0/name": {"/properties/nodes/items/$ref/properties/name/minLength", root + "/node.json#/properties/name/minLength"},
+		"additional_property_not_allowed /nodes/0/extra": {"/properties/nodes/items/$ref/additionalProperties", root + "/node.json#/additionalProperties"},
+		"number_gte /port":        {"/properties/port/$ref/minimum", root + "/root.json#/definitions/port/minimum"},
+		"number_any_of /endpoint": {"/properties/endpoint/anyOf", root + "/root.json#/properties/endpoint/anyOf"},
+	}
+
+	seen := map[string]bool{}
+
+	for _, e := range result.Exceptions {
+		name := e.Type + " " + e.Pointer
+
+		want, ok := expected[name]
+		if !ok {
+			continue
+		}
+		seen[name] = true
+
+		if got := [2]string{e.KeywordLocation, e.AbsoluteKeywordLocation}; got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+	}
+
+	for name := range expected {
+		if !seen[name] {
+			t.Errorf("no %s exception in %+v", name, result.Exceptions)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// The network keywords relate the networks and addresses of a document to
+// one another:
+//
+//	x-cidr-within     the CIDR instance lies within the network the JSON
+//	                  Pointer names, e.g. "3/vpc"
+//	x-ip-within       the IP address instance lies within the network the
+//	                  JSON Pointer names, e.g. "1/network"
+//	x-cidrs-disjoint  the networks of the items of the array instance do
+//	                  not overlap; the value is true, or a relative JSON
+//	                  Pointer from each item to its network, e.g. "0/cidr"
+//
+// Instances and referenced values that are missing or are not networks and
+// addresses are left to the other keywords, such as format.
+func init() {
+	extensionKeywords["x-cidr-within"] = extensionKeyword{compile: compileDataPointer, check: checkCIDRWithin}
+	extensionKeywords["x-ip-within"] = extensionKeyword{compile: compileDataPointer, check: checkIPWithin}
+	extensionKeywords["x-cidrs-disjoint"] = extensionKeyword{compile: compileItemPointer, check: checkCIDRsDisjoint}
+}
+
+// network returns the network that ref, the value of a keyword, names from
+// the instance at pointer, with its pointer and the string it is written
+// as.
+func (r *formatRun) network(pointer string, ref interface{}) (*net.IPNet, string, string, bool) {
+	text, at, ok := r.referenced(pointer, fmt.Sprint(ref))
+	if !ok {
+		return nil, "", "", false
+	}
+
+	_, network, err := net.ParseCIDR(text)
+	if err != nil {
+		return nil, "", "", false
+	}
+
+	return network, at, text, true
+}
+
+// networkFailure is the failure of a network keyword of p, naming the
+// network at location written as text.
+func networkFailure(p schemaPath, keyword string, pointer string, value interface{}, errorType string, message string, text string, at string) formatFailure {
+	return formatFailure{
+		owner:     p,
+		keyword:   keyword,
+		pointer:   pointer,
+		value:     value,
+		errorType: errorType,
+		text:      message,
+		params:    map[string]interface{}{"network": text, "location": at},
+	}
+}
+
+// withinNetwork reports whether inner is a subnet of, or the same network
+// as, outer.
+func withinNetwork(outer *net.IPNet, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
+
+// overlap reports whether networks a and b share addresses.
+func overlap(a *net.IPNet, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func checkCIDRWithin(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	_, inner, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil
+	}
+
+	outer, at, text, ok := r.network(pointer, p.node["x-cidr-within"])
+	if !ok || withinNetwork(outer, inner) {
+		return nil
+	}
+
+	return []formatFailure{networkFailure(p, "x-cidr-within", pointer, value, "cidr_within",
+		"Network is not within {{.network}}, the network at {{.location}}", text, at)}
+}
+
+func checkIPWithin(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil
+	}
+
+	network, at, text, ok := r.network(pointer, p.node["x-ip-within"])
+	if !ok || network.Contains(ip) {
+		return nil
+	}
+
+	return []formatFailure{networkFailure(p, "x-ip-within", pointer, value, "ip_within",
+		"Address is not within {{.network}}, the network at {{.location}}", text, at)}
+}
+
+func checkCIDRsDisjoint(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	ref := itemPointer(p.node["x-cidrs-disjoint"])
+
+	type member struct {
+		network *net.IPNet
+		at      string
+		text    string
+	}
+
+	var failures []formatFailure
+	var members []member
+
+	for i := range items {
+		network, at, text, ok := r.network(appendPointer(pointer, strconv.Itoa(i)), ref)
+		if !ok {
+			continue
+		}
+
+		for _, other := range members {
+			if overlap(other.network, network) {
+				failures = append(failures, networkFailure(p, "x-cidrs-disjoint", at, text, "cidrs_disjoint",
+					"Network overlaps {{.network}}, the network at {{.location}}", other.text, other.at))
+				break
+			}
+		}
+
+		members = append(members, member{network: network, at: at, text: text})
+	}
+
+	return failures
+}
@@ -0,0 +1,139 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNetworkKeywords(t *testing.T) {
+	v, err := New(WithSchemaBytes("kraken.json", []byte(`{
+		"definitions": {
+			"cidr": { "type": "string", "format": "cidr" },
+			"subnet": {
+				"properties": {
+					"cidr": { "$ref": "#/definitions/cidr", "x-cidr-within": "3/vpc" }
+				}
+			}
+		},
+		"properties": {
+			"vpc": { "$ref": "#/definitions/cidr" },
+			"subnet": {
+				"items": { "$ref": "#/definitions/subnet" },
+				"x-cidrs-disjoint": "0/cidr"
+			},
+			"cluster": {
+				"properties": {
+					"network": { "$ref": "#/definitions/cidr" },
+					"dns": { "format": "ipv4", "x-ip-within": "1/network" },
+					"services": { "x-ip-within": "/cluster/network" }
+				}
+			},
+			"pools": { "items": { "format": "cidr" }, "x-cidrs-disjoint": true }
+		}
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		errors   []string
+	}{
+		{`{"vpc": "10.0.0.0/16", "subnet": [{"cidr": "10.0.0.0/18"}, {"cidr": "10.0.64.0/18"}],
+		   "cluster": {"network": "10.32.0.0/12", "dns": "10.32.0.2", "services": "10.40.0.1"},
+		   "pools": ["10.1.0.0/16", "10.2.0.0/16"]}`, nil},
+		{`{"vpc": "10.0.0.0/16", "subnet": [{"cidr": "10.1.0.0/18"}]}`,
+			[]string{"cidr_within /subnet/0/cidr subnet.0.cidr: Network is not within 10.0.0.0/16, the network at /vpc"}},
+		{`{"vpc": "10.0.0.0/16", "subnet": [{"cidr": "10.0.0.0/8"}]}`,
+			[]string{"cidr_within /subnet/0/cidr subnet.0.cidr: Network is not within 10.0.0.0/16, the network at /vpc"}},
+		{`{"vpc": "10.0.0.0/16", "subnet": [{"cidr": "10.0.0.0/18"}, {"cidr": "10.0.64.0/18"}, {"cidr": "10.0.32.0/24"}]}`,
+			[]string{"cidrs_disjoint /subnet/2/cidr subnet.2.cidr: Network overlaps 10.0.0.0/18, the network at /subnet/0/cidr"}},
+		{`{"pools": ["10.0.0.0/8", "10.2.0.0/16"]}`,
+			[]string{"cidrs_disjoint /pools/1 pools.1: Network overlaps 10.0.0.0/8, the network at /pools/0"}},
+		{`{"cluster": {"network": "10.32.0.0/12", "dns": "10.0.0.2", "services": "10.48.0.1"}}`,
+			[]string{
+				"ip_within /cluster/dns cluster.dns: Address is not within 10.32.0.0/12, the network at /cluster/network",
+				"ip_within /cluster/services cluster.services: Address is not within 10.32.0.0/12, the network at /cluster/network",
+			}},
+
+		// what is missing or malformed is left to the other keywords
+		{`{"subnet": [{"cidr": "10.1.0.0/18"}], "cluster": {"dns": "10.0.0.2"}}`, nil},
+		{`{"vpc": "10.0.0.0/16", "subnet": [{"cidr": "nope"}]}`, []string{"format /subnet/0/cidr subnet.0.cidr: Does not match format 'cidr'"}},
+		{`{"vpc": "nope", "subnet": [{"cidr": "10.1.0.0/18"}]}`, []string{"format /vpc vpc: Does not match format 'cidr'"}},
+		{`{"cluster": {"network": "10.32.0.0/12", "dns": 10}}`, nil},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "config.json", []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: %v", test.document, err)
+			continue
+		}
+
+		var errors []string
+		for _, e := range result.Exceptions {
+			errors = append(errors, e.Type+" "+e.Pointer+" "+e.ErrorString)
+		}
+
+		if strings.Join(errors, "\n") != strings.Join(test.errors, "\n") || result.IsValid != (len(test.errors) == 0) {
+			t.Errorf("%s:\nexpected %v\ngot      %v", test.document, test.errors, errors)
+		}
+	}
+
+	// the exception is located at the offending value, and names the network
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("vpc: 10.0.0.0/16\nsubnet:\n  - cidr: 10.1.0.0/18\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Exceptions) != 1 {
+		t.Fatalf("expected 1 exception, got %+v", result.Exceptions)
+	}
+
+	e := result.Exceptions[0]
+	if e.Line != 3 || e.KeywordLocation != "/properties/subnet/items/$ref/properties/cidr/x-cidr-within" ||
+		e.Params["location"] != "/vpc" || e.Params["network"] != "10.0.0.0/16" {
+		t.Errorf("unexpected exception %+v", e)
+	}
+}
+
+func TestDataPointers(t *testing.T) {
+	tests := []struct {
+		pointer string
+		ref     string
+		target  string
+		ok      bool
+	}{
+		{"/cluster/dns", "1/network", "/cluster/network", true},
+		{"/cluster/dns", "0", "/cluster/dns", true},
+		{"/subnet/0/cidr", "3/vpc", "/vpc", true},
+		{"/subnet/0/cidr", "/vpc", "/vpc", true},
+		{"/subnet/0/cidr", "", "", true},
+		{"/a", "2/b", "", false},
+		{"/a", "01/b", "", false},
+		{"/a", "1b", "", false},
+		{"/a", "up/b", "", false},
+	}
+
+	for _, test := range tests {
+		target, ok := dataPointer(test.pointer, test.ref)
+		if target != test.target || ok != test.ok {
+			t.Errorf("%s from %s: expected %q %v, got %q %v", test.ref, test.pointer, test.target, test.ok, target, ok)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fileContentsNormalizer takes the contents of a config file & returns
+// the given document of it as the value tree encoding/json would decode it
+// into, with numbers kept as json.Number. It can take YAML or JSON data,
+// and decodes the document exactly once. The returned index maps nodes of
+// the document back to their line and column in fileContents.
+func fileContentsNormalizer(fileContents []byte, document yamlDocument) (interface{}, *positionIndex, error) {
+	contents := fileContents[document.start:document.end]
+	positions := newDocumentIndex(fileContents, document.start, document.end)
+
+	var value interface{}
+	var err error
+
+	if document.json {
+		value, err = decodeJSON(contents)
+	} else {
+		value, err = decodeYAML(contents)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return value, positions, nil
+}
+
+// isJSON reports whether b holds a single JSON value. It only scans b.
+func isJSON(b []byte) bool {
+	return json.Valid(b)
+}
+
+// decodeJSON decodes a JSON document the way gojsonschema expects it, with
+// numbers kept as json.Number.
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// decodeYAML decodes a YAML document into the value tree decodeJSON would
+// return for its JSON equivalent. Keys are turned into strings the way
+// github.com/ghodss/yaml does, and numbers into json.Number without going
+// through their JSON text.
+func decodeYAML(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	return jsonValue(value)
+}
+
+// jsonValue converts a value decoded by gopkg.in/yaml.v2 into its JSON
+// equivalent.
+func jsonValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+
+		for key, item := range v {
+			name, err := jsonKey(key)
+			if err != nil {
+				return nil, err
+			}
+
+			if object[name], err = jsonValue(item); err != nil {
+				return nil, err
+			}
+		}
+
+		return object, nil
+
+	case []interface{}:
+		array := make([]interface{}, len(v))
+
+		for i, item := range v {
+			var err error
+			if array[i], err = jsonValue(item); err != nil {
+				return nil, err
+			}
+		}
+
+		return array, nil
+
+	case int:
+		return json.Number(strconv.Itoa(v)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10)), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("unsupported value %v; JSON has no infinite or NaN numbers", v)
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	}
+
+	// strings, booleans and null
+	return value, nil
+}
+
+// jsonKey turns a YAML mapping key into a JSON object key.
+func jsonKey(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case int:
+		return strconv.Itoa(k), nil
+	case int64:
+		return strconv.FormatInt(k, 10), nil
+	case uint64:
+		return strconv.FormatUint(k, 10), nil
+	case float64:
+		switch s := strconv.FormatFloat(k, 'g', -1, 32); s {
+		case "+Inf":
+			return ".inf", nil
+		case "-Inf":
+			return "-.inf", nil
+		case "NaN":
+			return ".nan", nil
+		default:
+			return s, nil
+		}
+	case bool:
+		return strconv.FormatBool(k), nil
+	}
+
+	return "", fmt.Errorf("unsupported mapping key %v of type %T", key, key)
+}
+
+// readLimited reads r to the end, failing once more than limit bytes have
+// been read. A limit of zero means no limit.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return ioutil.ReadAll(r)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("document exceeds the maximum size of %d bytes", limit)
+	}
+
+	return data, nil
+}
+
+// fileExists check if a file exists on the system
+func fileExists(name string) (bool, error) {
+	file, err := os.Stat(name)
+	if err != nil {
+		return !os.IsNotExist(err), err
+	}
+
+	if file.IsDir() {
+		return false, errors.New("invalid file specified; requested file is a directory, not a file")
+	}
+
+	return true, nil
+}
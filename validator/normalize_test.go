@@ -0,0 +1,155 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	value, err := decodeYAML([]byte(`
+big: 9007199254740993
+huge: 18446744073709551615
+ratio: 0.1
+exp: 1e+30
+1: int key
+true: bool key
+1.5: float key
+list: [a, 2, null, {nested: yes}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"big":   json.Number("9007199254740993"),
+		"huge":  json.Number("18446744073709551615"),
+		"ratio": json.Number("0.1"),
+		"exp":   json.Number("1e+30"),
+		"1":     "int key",
+		"true":  "bool key",
+		"1.5":   "float key",
+		"list":  []interface{}{"a", json.Number("2"), nil, map[string]interface{}{"nested": true}},
+	}
+
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("expected %#v, got %#v", expected, value)
+	}
+
+	if _, err := decodeYAML([]byte("n: .nan\n")); err == nil {
+		t.Error("expected an error for a NaN, which JSON cannot hold")
+	}
+}
+
+func TestDecodeJSONAndYAMLAgree(t *testing.T) {
+	fromJSON, err := decodeJSON([]byte(`{"a": [1, 2.5, "x", true, null], "b": {"c": 12345678901234567890}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromYAML, err := decodeYAML([]byte("a: [1, 2.5, x, true, ~]\nb:\n  c: 12345678901234567890\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(fromJSON, fromYAML) {
+		t.Errorf("expected JSON and YAML to decode alike, got %#v and %#v", fromJSON, fromYAML)
+	}
+}
+
+// largeConfig returns a config of n node pools, as YAML and as JSON.
+func largeConfig(n int) ([]byte, []byte) {
+	var yamlDoc, jsonDoc bytes.Buffer
+
+	yamlDoc.WriteString("name: large\nnodes:\n")
+	jsonDoc.WriteString(`{"name": "large", "nodes": [`)
+
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&yamlDoc, "- name: node-%d\n  network: 10.%d.%d.0/24\n  count: %d\n", i, i/256%256, i%256, i%9+1)
+
+		if i > 0 {
+			jsonDoc.WriteString(",")
+		}
+		fmt.Fprintf(&jsonDoc, `{"name": "node-%d", "network": "10.%d.%d.0/24", "count": %d}`, i, i/256%256, i%256, i%9+1)
+	}
+
+	jsonDoc.WriteString("]}")
+
+	return yamlDoc.Bytes(), jsonDoc.Bytes()
+}
+
+// BenchmarkValidateLarge measures time and allocations of validating a
+// large config, from its bytes to the result.
+func BenchmarkValidateLarge(b *testing.B) {
+	yamlDoc, jsonDoc := largeConfig(5000)
+
+	v, err := New(WithSchemaBytes("large.json", []byte(`{
+		"properties": {
+			"name": {"type": "string"},
+			"nodes": {"type": "array", "items": {"properties": {
+				"name": {"type": "string"},
+				"network": {"type": "string", "format": "cidr"},
+				"count": {"type": "integer", "minimum": 1}
+			}}}
+		}
+	}`)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, doc := range []struct {
+		name string
+		data []byte
+	}{{"yaml", yamlDoc}, {"json", jsonDoc}} {
+		b.Run(doc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(doc.data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				result, err := v.ValidateBytes(context.Background(), doc.name, doc.data)
+				if err != nil || !result.IsValid {
+					b.Fatalf("unexpected outcome %v %+v", err, result)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkNormalize measures decoding alone.
+func BenchmarkNormalize(b *testing.B) {
+	yamlDoc, jsonDoc := largeConfig(5000)
+
+	for _, doc := range []struct {
+		name string
+		data []byte
+	}{{"yaml", yamlDoc}, {"json", jsonDoc}} {
+		b.Run(doc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(doc.data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				documents := splitDocuments(doc.data)
+				if _, _, err := fileContentsNormalizer(doc.data, documents[0]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
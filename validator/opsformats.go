@@ -0,0 +1,324 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minPort = 1
+	maxPort = 65535
+)
+
+// examples of the PEM formats: a self-signed Ed25519 certificate, and its
+// public key.
+const (
+	examplePEMCertificate = "-----BEGIN CERTIFICATE-----\n" +
+		"MIGrMF+gAwIBAgIBATAFBgMrZXAwADAeFw0xNzAxMDEwMDAwMDBaFw00OTEyMzEw\n" +
+		"MDAwMDBaMAAwKjAFBgMrZXADIQADoQe/884Qvh1w3RjnS8CZZ+TWMJulDV8d3IZk\n" +
+		"ElUxuDAFBgMrZXADQQCDqJAG/qyySHBxE+qM53aFFnkLdywMX6jiRmWrlQ5QQVOG\n" +
+		"3wmMHxShQ+hBZqd0EaVwTukHLNklxz6IzekGcBwN\n" +
+		"-----END CERTIFICATE-----\n"
+	examplePEMPublicKey = "-----BEGIN PUBLIC KEY-----\n" +
+		"MCowBQYDK2VwAyEAA6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg=\n" +
+		"-----END PUBLIC KEY-----\n"
+)
+
+var (
+	iso8601DurationRegexp = regexp.MustCompile(`^P(\d+([.,]\d+)?Y)?(\d+([.,]\d+)?M)?(\d+([.,]\d+)?W)?(\d+([.,]\d+)?D)?(T(\d+([.,]\d+)?H)?(\d+([.,]\d+)?M)?(\d+([.,]\d+)?S)?)?$`)
+	cronDescriptors       = map[string]bool{
+		"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+		"@daily": true, "@midnight": true, "@hourly": true,
+	}
+)
+
+// cronField is the range and names of a field of a cron expression.
+type cronField struct {
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// isGoDuration reports whether s is a duration time.ParseDuration accepts,
+// such as 600s or 1h30m.
+func isGoDuration(s string) bool {
+	_, err := time.ParseDuration(s)
+
+	return err == nil
+}
+
+// isISO8601Duration reports whether s is an ISO 8601 duration, such as PT10M
+// or P1DT12H, with at least one component.
+func isISO8601Duration(s string) bool {
+	return iso8601DurationRegexp.MatchString(s) && s != "P" && !strings.HasSuffix(s, "T")
+}
+
+// isCron reports whether s is a cron schedule: five fields (minute, hour,
+// day of month, month and day of week) or one of the @yearly, @monthly,
+// @weekly, @daily, @hourly descriptors or @every followed by a duration.
+func isCron(s string) bool {
+	if strings.HasPrefix(s, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(s, "@every ")))
+		return err == nil && d > 0
+	}
+	if cronDescriptors[s] {
+		return true
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return false
+	}
+
+	for i, field := range fields {
+		if !cronFields[i].valid(field) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valid reports whether s, a comma separated list of *, values and ranges,
+// each with an optional /step, fits the field.
+func (f cronField) valid(s string) bool {
+	for _, item := range strings.Split(s, ",") {
+		if i := strings.Index(item, "/"); i >= 0 {
+			step, err := strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return false
+			}
+			item = item[:i]
+		}
+
+		if item == "*" {
+			continue
+		}
+
+		bounds := strings.SplitN(item, "-", 2)
+		low, ok := f.value(bounds[0])
+		if !ok {
+			return false
+		}
+		if len(bounds) == 2 {
+			high, ok := f.value(bounds[1])
+			if !ok || high < low {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// value parses a number or name of the field.
+func (f cronField) value(s string) (int, bool) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, true
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+
+	return n, err == nil && n >= f.min && n <= f.max
+}
+
+// isBase64 reports whether s is standard, padded base64. Line breaks, as in
+// YAML block scalars, are allowed.
+func isBase64(s string) bool {
+	_, err := base64.StdEncoding.DecodeString(s)
+
+	return err == nil
+}
+
+// pemBlocks decodes the PEM blocks of s, which must hold at least one and
+// nothing else but white space.
+func pemBlocks(s string) ([]*pem.Block, bool) {
+	var blocks []*pem.Block
+
+	rest := []byte(s)
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+		rest = remaining
+	}
+
+	return blocks, len(blocks) > 0 && len(bytes.TrimSpace(rest)) == 0
+}
+
+// isPEMCertificate reports whether s is one or more PEM encoded X.509
+// certificates, as in a certificate chain.
+func isPEMCertificate(s string) bool {
+	blocks, ok := pemBlocks(s)
+	if !ok {
+		return false
+	}
+
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			return false
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPEMKey reports whether s is a single PEM encoded private or public key,
+// in PKCS #1, PKCS #8, SEC 1 or PKIX form.
+func isPEMKey(s string) bool {
+	blocks, ok := pemBlocks(s)
+	if !ok || len(blocks) != 1 {
+		return false
+	}
+
+	var err error
+	switch block := blocks[0]; block.Type {
+	case "RSA PRIVATE KEY":
+		_, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		_, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		_, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		_, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		_, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return false
+	}
+
+	return err == nil
+}
+
+// PortFormatChecker checks TCP and UDP port numbers, 1 to 65535, written
+// as integers or as strings of digits.
+type PortFormatChecker struct{}
+
+// IsFormat reports whether input is a port number.
+func (f PortFormatChecker) IsFormat(input interface{}) bool {
+	switch v := input.(type) {
+	case string:
+		return isPort(v)
+	case json.Number:
+		return isPort(v.String())
+	}
+
+	return true
+}
+
+// Types returns "string" and "integer".
+func (f PortFormatChecker) Types() []string {
+	return []string{"string", "integer"}
+}
+
+func isPort(s string) bool {
+	n, ok := port(s)
+
+	return ok && n >= minPort && n <= maxPort
+}
+
+// port parses a port number written in decimal digits.
+func port(s string) (int, bool) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(s)
+
+	return n, err == nil
+}
+
+// isPortRange reports whether s is a from-to range of port numbers, from
+// not above to.
+func isPortRange(s string) bool {
+	i := strings.Index(s, "-")
+	if i < 0 || !isPort(s[:i]) || !isPort(s[i+1:]) {
+		return false
+	}
+
+	from, _ := port(s[:i])
+	to, _ := port(s[i+1:])
+
+	return from <= to
+}
+
+// isMAC reports whether s is an IEEE 802 MAC-48, EUI-48, EUI-64 or 20-octet
+// IP over InfiniBand address, as net.ParseMAC accepts.
+func isMAC(s string) bool {
+	_, err := net.ParseMAC(s)
+
+	return err == nil
+}
+
+// isAbsolutePath reports whether s is an absolute POSIX path.
+func isAbsolutePath(s string) bool {
+	return path.IsAbs(s) && !strings.ContainsRune(s, 0)
+}
+
+// isRelativePath reports whether s is a non-empty relative POSIX path.
+func isRelativePath(s string) bool {
+	return s != "" && !path.IsAbs(s) && !strings.ContainsRune(s, 0)
+}
+
+// isRE2 reports whether s is a regular expression in the RE2 syntax of
+// Go's regexp package.
+func isRE2(s string) bool {
+	_, err := regexp.Compile(s)
+
+	return err == nil
+}
+
+// operationalFormats are the general purpose formats of DefaultFormats.
+func operationalFormats() []Format {
+	return []Format{
+		{"duration", "a Go duration", []string{"600s", "1h30m"}, []string{"10", "1d"}, StringFormat(isGoDuration)},
+		{"iso8601-duration", "an ISO 8601 duration", []string{"PT10M", "P1DT12H"}, []string{"P", "10m"}, StringFormat(isISO8601Duration)},
+		{"cron", "a cron schedule of five fields, or a descriptor such as @daily or @every 1h", []string{"*/15 * * * *", "0 3 * * mon-fri"}, []string{"* * * *", "60 * * * *"}, StringFormat(isCron)},
+		{"base64", "standard base64 with padding", []string{"aGVsbG8="}, []string{"aGVsbG8", "a$b="}, StringFormat(isBase64)},
+		{"pem-certificate", "one or more PEM encoded X.509 certificates", []string{examplePEMCertificate}, []string{examplePEMPublicKey}, StringFormat(isPEMCertificate)},
+		{"pem-key", "a PEM encoded private or public key", []string{examplePEMPublicKey}, []string{examplePEMCertificate, "-----BEGIN PUBLIC KEY-----\nMCow\n-----END PUBLIC KEY-----\n"}, StringFormat(isPEMKey)},
+		{"port", "a port number from 1 to 65535", []string{"443", "8080"}, []string{"0", "65536"}, PortFormatChecker{}},
+		{"port-range", "a from-to range of port numbers", []string{"30000-32767"}, []string{"80", "90-80"}, StringFormat(isPortRange)},
+		{"mac", "a MAC address", []string{"00:1b:44:11:3a:b7", "00-1B-44-11-3A-B7"}, []string{"00:1b:44:11:3a"}, StringFormat(isMAC)},
+		{"absolute-path", "an absolute POSIX path", []string{"/etc/kubernetes/admin.conf"}, []string{"etc/kubernetes"}, StringFormat(isAbsolutePath)},
+		{"relative-path", "a relative POSIX path", []string{"manifests/app.yaml", "../charts"}, []string{"/etc", ""}, StringFormat(isRelativePath)},
+		{"re2", "a regular expression in RE2 syntax", []string{"^v[0-9]+$"}, []string{"(?<=a)b", "[a-"}, StringFormat(isRE2)},
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configures a Validator. Options are applied in order by New.
+type Option func(*Validator) error
+
+// WithSchema validates against the schema at location, which is parsed with
+// ParseSource: a file path, relative or starting with "~", a file:// URI,
+// an http:// or https:// URL, or "-" for standard input.
+func WithSchema(location string) Option {
+	return func(v *Validator) error {
+		src, err := ParseSource(location)
+		if err != nil {
+			return fmt.Errorf("invalid schema location: %v", err)
+		}
+
+		return WithSchemaSource(src)(v)
+	}
+}
+
+// WithSchemaSource validates against the schema read from src. A schema read
+// from standard input resolves its relative $refs against the working
+// directory.
+func WithSchemaSource(src Source) Option {
+	return func(v *Validator) error {
+		switch {
+		case src.IsURL():
+			return WithSchemaURL(src.Location)(v)
+		case src.IsStdin():
+			v.schemaURI = ""
+		case src.path == "":
+			return errors.New("empty schema source given")
+		default:
+			v.schemaURI = src.URI()
+		}
+
+		v.schemaName = src.Location
+		v.schemaData = nil
+		v.schemaStdin = src.IsStdin()
+
+		return nil
+	}
+}
+
+// WithSchemaFile validates against the JSON schema stored at path, written in
+// JSON or YAML. A relative path is resolved against the working directory
+// and a leading "~" is expanded to the home directory.
+func WithSchemaFile(path string) Option {
+	return func(v *Validator) error {
+		if path == "" {
+			return errors.New("empty schema path given")
+		}
+
+		src, err := fileSourceOf(path)
+		if err != nil {
+			return err
+		}
+
+		return WithSchemaSource(src)(v)
+	}
+}
+
+// WithSchemaURL validates against the JSON schema served at rawURL over
+// HTTP(S). Relative $refs inside it are fetched from the same server.
+func WithSchemaURL(rawURL string) Option {
+	return func(v *Validator) error {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return err
+		}
+
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid schema URL %q; expected an absolute http(s) URL", rawURL)
+		}
+
+		v.schemaName = rawURL
+		v.schemaURI = u.String()
+		v.schemaData = nil
+		v.schemaStdin = false
+
+		return nil
+	}
+}
+
+// WithSchemaBytes validates against the JSON schema held in data, written in
+// JSON or YAML. name identifies the schema in results; relative $refs inside
+// the schema are resolved as if it were the path of the schema file.
+func WithSchemaBytes(name string, data []byte) Option {
+	return func(v *Validator) error {
+		if len(data) == 0 {
+			return errors.New("empty schema given")
+		}
+
+		v.schemaName = name
+		v.schemaURI = ""
+		v.schemaData = data
+		v.schemaStdin = false
+
+		return nil
+	}
+}
+
+// WithHTTPClient sets the client used to fetch schemas over HTTP(S). The
+// client's own Timeout takes precedence over WithHTTPTimeout.
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *Validator) error {
+		if client == nil {
+			return errors.New("nil HTTP client given")
+		}
+
+		v.httpClient = client
+
+		return nil
+	}
+}
+
+// WithHTTPTimeout bounds each request made to fetch a schema. It defaults
+// to DefaultHTTPTimeout.
+func WithHTTPTimeout(d time.Duration) Option {
+	return func(v *Validator) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid HTTP timeout %s", d)
+		}
+
+		v.httpTimeout = d
+
+		return nil
+	}
+}
+
+// WithHTTPHeader adds a header, such as Authorization, to the requests made
+// to fetch the schema. Headers are only sent to the host serving the root
+// schema, never to other hosts its $refs point at.
+func WithHTTPHeader(name string, value string) Option {
+	return func(v *Validator) error {
+		if name == "" {
+			return errors.New("empty HTTP header name given")
+		}
+
+		v.httpHeaders.Add(name, value)
+
+		return nil
+	}
+}
+
+// WithMaxSchemaSize refuses schema documents, including referenced ones,
+// larger than n bytes. It defaults to DefaultMaxSchemaSize; zero means no
+// limit.
+func WithMaxSchemaSize(n int64) Option {
+	return func(v *Validator) error {
+		if n < 0 {
+			return fmt.Errorf("invalid maximum schema size %d", n)
+		}
+
+		v.maxSchemaSize = n
+
+		return nil
+	}
+}
+
+// WithFormats sets the custom format checkers available to the schema. It
+// defaults to DefaultFormats.
+func WithFormats(formats *FormatRegistry) Option {
+	return func(v *Validator) error {
+		if formats == nil {
+			return errors.New("nil format registry given")
+		}
+
+		v.formats = formats
+
+		return nil
+	}
+}
+
+// WithLocale sets the message templates used to describe validation errors.
+func WithLocale(locale Locale) Option {
+	return func(v *Validator) error {
+		v.locale = locale
+
+		return nil
+	}
+}
+
+// WithMaxDocumentSize refuses documents larger than n bytes. Zero, the
+// default, means no limit.
+func WithMaxDocumentSize(n int64) Option {
+	return func(v *Validator) error {
+		if n < 0 {
+			return fmt.Errorf("invalid maximum document size %d", n)
+		}
+
+		v.maxDocumentSize = n
+
+		return nil
+	}
+}
+
+// WithMaxErrors stops recording exceptions for a document after n of them.
+// Zero, the default, means no limit.
+func WithMaxErrors(n int) Option {
+	return func(v *Validator) error {
+		if n < 0 {
+			return fmt.Errorf("invalid maximum error count %d", n)
+		}
+
+		v.maxErrors = n
+
+		return nil
+	}
+}
+
+// WithSingleDocument makes ValidateDocuments and ValidateFileDocuments
+// reject YAML streams holding more than one document.
+func WithSingleDocument() Option {
+	return func(v *Validator) error {
+		v.singleDocument = true
+		return nil
+	}
+}
+
+// WithSchemaCache makes the Validator take its compiled schema from cache,
+// and compile it into cache when missing.
+func WithSchemaCache(cache *SchemaCache) Option {
+	return func(v *Validator) error {
+		v.cache = cache
+		return nil
+	}
+}
+
+// WithCauses records, for anyOf and oneOf errors, the errors of every branch
+// the value fails as ExceptionDetail.Causes, which Structured nests under
+// them. gojsonschema only reports the branch it deems closest, so each
+// branch is validated again; this is left out unless asked for.
+func WithCauses() Option {
+	return func(v *Validator) error {
+		v.causes = true
+		return nil
+	}
+}
+
+// WithRules checks documents against the policy rules of rules, after the
+// schema. Breaking a rule raises an exception of type "rule" carrying the
+// id and severity of the rule; only errors make a document invalid.
+func WithRules(rules *RuleSet) Option {
+	return func(v *Validator) error {
+		if rules == nil {
+			return errors.New("nil rules given")
+		}
+
+		v.rules = append(v.rules, rules)
+
+		return nil
+	}
+}
+
+// WithRulesFile checks documents against the policy rules of the rules
+// file at path, read by New with LoadRules. See WithRules.
+func WithRulesFile(path string) Option {
+	return func(v *Validator) error {
+		if path == "" {
+			return errors.New("empty rules path given")
+		}
+
+		v.rulesFiles = append(v.rulesFiles, path)
+
+		return nil
+	}
+}
+
+// WithStdin reads the schema and configs given as "-" from r rather than
+// from os.Stdin.
+func WithStdin(r io.Reader) Option {
+	return func(v *Validator) error {
+		if r == nil {
+			return errors.New("nil stdin given")
+		}
+
+		v.stdin = r
+
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pointerEscaper and pointerUnescaper encode reference tokens of RFC 6901
+// JSON Pointers.
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// appendPointer returns pointer extended by the reference token token.
+func appendPointer(pointer string, token string) string {
+	return pointer + "/" + pointerEscaper.Replace(token)
+}
+
+// parentPointer returns the pointer of the value holding the one at
+// pointer, which must not be the empty pointer.
+func parentPointer(pointer string) string {
+	return pointer[:strings.LastIndex(pointer, "/")]
+}
+
+// splitPointer returns the unescaped reference tokens of pointer. The empty
+// pointer, the whole document, has no tokens.
+func splitPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = pointerUnescaper.Replace(token)
+	}
+
+	return tokens
+}
+
+// resolvePointer returns the value pointer refers to inside document, a
+// tree of map[string]interface{} and []interface{} values.
+func resolvePointer(document interface{}, pointer string) (interface{}, bool) {
+	if pointer != "" && !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	node := document
+	for _, token := range splitPointer(pointer) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, false
+			}
+			node = child
+
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(n) || (len(token) > 1 && token[0] == '0') {
+				return nil, false
+			}
+			node = n[index]
+
+		default:
+			return nil, false
+		}
+	}
+
+	return node, true
+}
+
+// dataPointer returns the absolute pointer of the value ref names from the
+// instance at pointer. ref is either an absolute JSON Pointer, from the
+// root of the document, or a relative JSON Pointer: a number of levels to
+// go up from the instance followed by a JSON Pointer, e.g. "1/network" for
+// the network member of the object holding the instance.
+func dataPointer(pointer string, ref string) (string, bool) {
+	if ref == "" || strings.HasPrefix(ref, "/") {
+		return ref, true
+	}
+
+	up, rest, ok := splitRelativePointer(ref)
+	if !ok {
+		return "", false
+	}
+
+	for ; up > 0; up-- {
+		if pointer == "" {
+			return "", false
+		}
+		pointer = parentPointer(pointer)
+	}
+
+	return pointer + rest, true
+}
+
+// splitRelativePointer splits a relative JSON Pointer into the number of
+// levels it goes up and the JSON Pointer that follows.
+func splitRelativePointer(ref string) (int, string, bool) {
+	digits := len(ref) - len(strings.TrimLeft(ref, "0123456789"))
+	if digits == 0 || digits > 1 && ref[0] == '0' {
+		return 0, "", false
+	}
+
+	up, err := strconv.Atoi(ref[:digits])
+	if err != nil {
+		return 0, "", false
+	}
+
+	rest := ref[digits:]
+	if rest != "" && !strings.HasPrefix(rest, "/") {
+		return 0, "", false
+	}
+
+	return up, rest, true
+}
+
+// isDataPointer reports whether ref is an absolute or relative JSON
+// Pointer, as dataPointer takes.
+func isDataPointer(ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "/") {
+		return true
+	}
+
+	_, _, ok := splitRelativePointer(ref)
+
+	return ok
+}
@@ -0,0 +1,709 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Span is a region of a source document. Lines and columns are 1-based and
+// columns count characters. EndColumn is the column just past the region.
+type Span struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// rawNode holds the byte offsets of one indexed node. Keys are only set for
+// members of an object.
+type rawNode struct {
+	hasKey     bool
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// positionIndex maps the JSON Pointers of a parsed YAML or JSON document to
+// where they were written. It is built on first use, so documents that
+// validate never pay for it.
+type positionIndex struct {
+	source []byte
+	start  int
+	end    int
+
+	once       sync.Once
+	nodes      map[string]*rawNode
+	lineStarts []int
+}
+
+// newPositionIndex returns the position index of a YAML or JSON document.
+func newPositionIndex(source []byte) *positionIndex {
+	return &positionIndex{source: source, end: len(source)}
+}
+
+// newDocumentIndex returns the position index of the document held in
+// source[start:end], a document of a YAML stream. Its positions are those
+// in the whole stream.
+func newDocumentIndex(source []byte, start int, end int) *positionIndex {
+	return &positionIndex{source: source, start: start, end: end}
+}
+
+// lookup returns the span of the value at pointer, or of the key of its
+// member when key is set. Pointers that were not indexed, for instance
+// values merged in through YAML aliases, fall back to their closest indexed
+// ancestor.
+func (p *positionIndex) lookup(pointer string, key bool) (Span, bool) {
+	if p == nil {
+		return Span{}, false
+	}
+
+	p.once.Do(p.build)
+
+	for {
+		if node, ok := p.nodes[pointer]; ok {
+			if key && node.hasKey {
+				return p.span(node.keyStart, node.keyEnd), true
+			}
+			return p.span(node.valueStart, node.valueEnd), true
+		}
+
+		if pointer == "" {
+			return Span{}, false
+		}
+
+		pointer = pointer[:strings.LastIndex(pointer, "/")]
+		key = false
+	}
+}
+
+// contextPointer returns the JSON Pointer of the instance a validation error
+// was raised on.
+func contextPointer(desc gojsonschema.ResultError) string {
+	if rebased, ok := desc.(*rebasedError); ok {
+		return rebased.base + contextPointer(rebased.ResultError)
+	}
+
+	// gojsonschema joins the path with dots, which object keys may contain
+	tokens := strings.Split(desc.Context().String("\x00"), "\x00")
+
+	pointer := ""
+	for _, token := range tokens[1:] {
+		pointer = appendPointer(pointer, token)
+	}
+
+	return pointer
+}
+
+// errorPointer returns the JSON Pointer of the node a validation error is
+// about, and whether the error is about its key rather than its value. It
+// differs from the context of the error for unexpected properties, which are
+// reported on their object.
+func errorPointer(desc gojsonschema.ResultError) (string, bool) {
+	pointer := contextPointer(desc)
+
+	if desc.Type() == "additional_property_not_allowed" {
+		if property, ok := desc.Details()["property"].(string); ok {
+			return appendPointer(pointer, property), true
+		}
+	}
+
+	return pointer, false
+}
+
+func (p *positionIndex) build() {
+	p.nodes = map[string]*rawNode{}
+	p.lineStarts = []int{0}
+
+	for i, c := range p.source {
+		if c == '\n' {
+			p.lineStarts = append(p.lineStarts, i+1)
+		}
+	}
+
+	x := &indexer{src: p.source, nodes: p.nodes, lineStarts: p.lineStarts}
+	x.document(p.start, p.end)
+}
+
+// span converts byte offsets into a Span.
+func (p *positionIndex) span(start int, end int) Span {
+	line, column := p.lineColumn(start)
+	endLine, endColumn := p.lineColumn(end)
+
+	return Span{Line: line, Column: column, EndLine: endLine, EndColumn: endColumn}
+}
+
+func (p *positionIndex) lineColumn(offset int) (int, int) {
+	line := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > offset }) - 1
+	if line < 0 {
+		line = 0
+	}
+
+	return line + 1, utf8.RuneCount(p.source[p.lineStarts[line]:offset]) + 1
+}
+
+// indexer records node offsets while scanning a document. JSON, and YAML
+// flow collections, are scanned token by token; YAML block collections line
+// by line, following indentation. It only needs to be as strict as locating
+// nodes requires: the document has been parsed properly already.
+type indexer struct {
+	src        []byte
+	nodes      map[string]*rawNode
+	lineStarts []int
+}
+
+// document indexes the YAML or JSON document held in src[start:end].
+func (x *indexer) document(start int, end int) {
+	off := x.skipSpace(start, end)
+	if off >= end {
+		return
+	}
+
+	if c := x.src[off]; c == '{' || c == '[' {
+		x.flowValue(off, end, "", nil)
+		return
+	}
+
+	b := &blockIndexer{indexer: x}
+	b.run(start, end)
+}
+
+func (x *indexer) set(pointer string, key *rawNode, valueStart int, valueEnd int) *rawNode {
+	node := &rawNode{valueStart: valueStart, valueEnd: valueEnd}
+
+	if key != nil {
+		node.hasKey = true
+		node.keyStart, node.keyEnd = key.keyStart, key.keyEnd
+	}
+
+	x.nodes[pointer] = node
+
+	return node
+}
+
+// skipSpace skips whitespace, newlines and comments.
+func (x *indexer) skipSpace(off int, end int) int {
+	for off < end {
+		switch x.src[off] {
+		case ' ', '\t', '\r', '\n':
+			off++
+		case '#':
+			for off < end && x.src[off] != '\n' {
+				off++
+			}
+		default:
+			return off
+		}
+	}
+
+	return off
+}
+
+// flowValue indexes the JSON or YAML flow value starting at off as pointer
+// and returns the offset just past it.
+func (x *indexer) flowValue(off int, end int, pointer string, key *rawNode) int {
+	if off >= end {
+		return off
+	}
+
+	switch x.src[off] {
+	case '{':
+		node := x.set(pointer, key, off, off)
+		node.valueEnd = x.flowMapping(off+1, end, pointer)
+		return node.valueEnd
+
+	case '[':
+		node := x.set(pointer, key, off, off)
+		node.valueEnd = x.flowSequence(off+1, end, pointer)
+		return node.valueEnd
+
+	case '"', '\'':
+		valueEnd := x.quotedEnd(off, end)
+		x.set(pointer, key, off, valueEnd)
+		return valueEnd
+
+	default:
+		valueEnd := x.plainEnd(off, end, true)
+		x.set(pointer, key, off, valueEnd)
+		return valueEnd
+	}
+}
+
+func (x *indexer) flowMapping(off int, end int, pointer string) int {
+	for {
+		off = x.skipSpace(off, end)
+		if off >= end {
+			return off
+		}
+
+		switch x.src[off] {
+		case '}':
+			return off + 1
+		case ',':
+			off++
+			continue
+		}
+
+		keyStart := off
+		keyEnd := off
+		if c := x.src[off]; c == '"' || c == '\'' {
+			keyEnd = x.quotedEnd(off, end)
+		} else {
+			keyEnd = x.plainEnd(off, end, true)
+		}
+
+		key := &rawNode{keyStart: keyStart, keyEnd: keyEnd}
+		member := appendPointer(pointer, x.keyText(keyStart, keyEnd))
+
+		off = x.skipSpace(keyEnd, end)
+		if off < end && x.src[off] == ':' {
+			off = x.flowValue(x.skipSpace(off+1, end), end, member, key)
+		} else {
+			x.set(member, key, keyStart, keyEnd)
+		}
+
+		if keyEnd == keyStart && off == keyStart {
+			// not a key we understand; give up on this mapping
+			return off
+		}
+	}
+}
+
+func (x *indexer) flowSequence(off int, end int, pointer string) int {
+	for index := 0; ; index++ {
+		off = x.skipSpace(off, end)
+		if off >= end {
+			return off
+		}
+
+		if x.src[off] == ']' {
+			return off + 1
+		}
+
+		next := x.flowValue(off, end, appendPointer(pointer, strconv.Itoa(index)), nil)
+
+		off = x.skipSpace(next, end)
+		if off < end && x.src[off] == ',' {
+			off++
+		} else if off == next && (off >= end || x.src[off] != ']') {
+			return off
+		}
+	}
+}
+
+// quotedEnd returns the offset just past the quoted scalar starting at off.
+func (x *indexer) quotedEnd(off int, end int) int {
+	quote := x.src[off]
+
+	for i := off + 1; i < end; i++ {
+		switch x.src[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			if quote == '\'' && i+1 < end && x.src[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+
+	return end
+}
+
+// plainEnd returns the offset just past the plain scalar starting at off,
+// ending at a comment, the end of the line, a ": " separator, or in flow
+// context at a flow indicator. Trailing blanks are not part of it.
+func (x *indexer) plainEnd(off int, end int, flow bool) int {
+	i := off
+
+loop:
+	for ; i < end; i++ {
+		switch c := x.src[i]; c {
+		case '\n', '\r':
+			break loop
+		case '#':
+			if i > off && (x.src[i-1] == ' ' || x.src[i-1] == '\t') {
+				break loop
+			}
+		case ':':
+			if i+1 >= end || isBlank(x.src[i+1]) || (flow && isFlowIndicator(x.src[i+1])) {
+				break loop
+			}
+		case ',', '[', ']', '{', '}':
+			if flow {
+				break loop
+			}
+		}
+	}
+
+	for i > off && isBlank(x.src[i-1]) {
+		i--
+	}
+
+	return i
+}
+
+// keyText returns the text of a mapping key, unquoted.
+func (x *indexer) keyText(start int, end int) string {
+	raw := x.src[start:end]
+
+	if len(raw) >= 2 && raw[0] == '"' {
+		var text string
+		if json.Unmarshal(raw, &text) == nil {
+			return text
+		}
+		return string(raw[1 : len(raw)-1])
+	}
+
+	if len(raw) >= 2 && raw[0] == '\'' {
+		return strings.Replace(string(raw[1:len(raw)-1]), "''", "'", -1)
+	}
+
+	return string(raw)
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
+
+// blockFrame is a YAML block collection being indexed.
+type blockFrame struct {
+	indent  int
+	pointer string
+	seq     bool
+	next    int
+}
+
+// blockIndexer indexes YAML block collections line by line.
+type blockIndexer struct {
+	*indexer
+
+	frames []*blockFrame
+
+	// pending is the node whose value, if any, starts on a later line.
+	pending       string
+	pendingIndent int
+	hasPending    bool
+
+	// blockIndent is the indentation of the line owning an active literal
+	// or folded block scalar, or -1.
+	blockIndent  int
+	blockPointer string
+
+	// last is the latest plain scalar, which continuation lines extend.
+	last    string
+	hasLast bool
+
+	// resume skips lines already consumed by a multi-line value.
+	resume int
+}
+
+func (b *blockIndexer) run(start int, end int) {
+	b.blockIndent = -1
+
+	root := b.set("", nil, -1, start)
+
+	for line := start; line < end; {
+		lineEnd := bytes.IndexByte(b.src[line:end], '\n')
+		if lineEnd < 0 {
+			lineEnd = end
+		} else {
+			lineEnd += line
+		}
+
+		next := lineEnd + 1
+		if lineEnd > line && b.src[lineEnd-1] == '\r' {
+			lineEnd--
+		}
+
+		if line >= b.resume {
+			b.line(line, lineEnd)
+		}
+
+		if content := b.skipSpace(line, lineEnd); content < lineEnd && !b.isMarker(line, lineEnd) {
+			if root.valueStart < 0 {
+				root.valueStart = content
+			}
+			root.valueEnd = lineEnd
+		}
+
+		line = next
+	}
+
+	if root.valueStart < 0 {
+		root.valueStart = start
+	}
+}
+
+// isMarker reports whether the line is a document marker or a directive.
+func (b *blockIndexer) isMarker(line int, lineEnd int) bool {
+	text := b.src[line:lineEnd]
+
+	if bytes.HasPrefix(text, []byte("---")) || bytes.HasPrefix(text, []byte("...")) {
+		return len(text) == 3 || isBlank(text[3])
+	}
+
+	return len(text) > 0 && text[0] == '%'
+}
+
+func (b *blockIndexer) line(line int, lineEnd int) {
+	off := line
+	for off < lineEnd && b.src[off] == ' ' {
+		off++
+	}
+	indent := off - line
+
+	if b.blockIndent >= 0 {
+		if off == lineEnd || indent > b.blockIndent {
+			b.extend(b.blockPointer, lineEnd)
+			return
+		}
+		b.blockIndent = -1
+	}
+
+	if off == lineEnd || b.src[off] == '#' || b.isMarker(line, lineEnd) {
+		return
+	}
+
+	b.entry(off, lineEnd, indent)
+}
+
+// entry indexes the block content starting at off, in column indent.
+func (b *blockIndexer) entry(off int, lineEnd int, indent int) {
+	for len(b.frames) > 0 && b.top().indent > indent {
+		b.frames = b.frames[:len(b.frames)-1]
+	}
+
+	dash := b.src[off] == '-' && (off+1 == lineEnd || isBlank(b.src[off+1]))
+
+	// a sequence may sit at the indentation of the key owning it
+	if len(b.frames) > 0 && b.top().indent == indent && b.top().seq && !dash {
+		b.frames = b.frames[:len(b.frames)-1]
+	}
+
+	if dash {
+		frame := b.frame(indent, true, off)
+		pointer := appendPointer(frame.pointer, strconv.Itoa(frame.next))
+		frame.next++
+
+		b.set(pointer, nil, off, off+1)
+		b.extendFrames(off + 1)
+
+		value := off + 1
+		for value < lineEnd && isBlank(b.src[value]) {
+			value++
+		}
+
+		b.pending, b.pendingIndent, b.hasPending = pointer, indent, true
+
+		if value == lineEnd || b.src[value] == '#' {
+			return
+		}
+
+		if b.isEntry(value, lineEnd) {
+			b.entry(value, lineEnd, indent+(value-off))
+			return
+		}
+
+		b.hasPending = false
+		b.value(pointer, nil, value, lineEnd)
+		return
+	}
+
+	keyEnd, value, ok := b.key(off, lineEnd)
+	if !ok {
+		// the continuation of a multi-line plain scalar
+		if b.hasLast {
+			b.extend(b.last, lineEnd)
+		}
+		return
+	}
+
+	frame := b.frame(indent, false, off)
+	pointer := appendPointer(frame.pointer, b.keyText(off, keyEnd))
+	key := &rawNode{keyStart: off, keyEnd: keyEnd}
+
+	// skip anchors and tags
+	for value < lineEnd && (b.src[value] == '&' || b.src[value] == '!') {
+		for value < lineEnd && !isBlank(b.src[value]) {
+			value++
+		}
+		for value < lineEnd && isBlank(b.src[value]) {
+			value++
+		}
+	}
+
+	if value >= lineEnd || b.src[value] == '#' {
+		b.set(pointer, key, off, keyEnd)
+		b.extendFrames(keyEnd)
+		b.pending, b.pendingIndent, b.hasPending = pointer, indent, true
+		return
+	}
+
+	b.value(pointer, key, value, lineEnd)
+}
+
+// isEntry reports whether the content at off opens a block collection
+// entry: a sequence item or a mapping key.
+func (b *blockIndexer) isEntry(off int, lineEnd int) bool {
+	if b.src[off] == '-' && (off+1 == lineEnd || isBlank(b.src[off+1])) {
+		return true
+	}
+
+	_, _, ok := b.key(off, lineEnd)
+	return ok
+}
+
+// value indexes a value written on the same line as its key or dash.
+func (b *blockIndexer) value(pointer string, key *rawNode, off int, lineEnd int) {
+	b.hasLast = false
+
+	switch b.src[off] {
+	case '|', '>':
+		b.set(pointer, key, off, lineEnd)
+		b.blockIndent = b.indentOf(off)
+		b.blockPointer = pointer
+
+	case '[', '{':
+		end := b.flowValue(off, len(b.src), pointer, key)
+		b.resume = end
+		lineEnd = end
+
+	case '"', '\'':
+		end := b.quotedEnd(off, len(b.src))
+		b.set(pointer, key, off, end)
+		b.resume = end
+		lineEnd = end
+
+	default:
+		end := b.plainEnd(off, lineEnd, false)
+		b.set(pointer, key, off, end)
+		b.last, b.hasLast = pointer, true
+		lineEnd = end
+	}
+
+	b.extendFrames(lineEnd)
+}
+
+// key returns the end of the mapping key starting at off and the start of
+// its value, if off starts a "key: value" entry.
+func (b *blockIndexer) key(off int, lineEnd int) (int, int, bool) {
+	keyEnd := off
+
+	switch b.src[off] {
+	case '"', '\'':
+		keyEnd = b.quotedEnd(off, lineEnd)
+	case '[', '{', '-', '#', '|', '>', '*', '&', '!', '?':
+		return 0, 0, false
+	default:
+		keyEnd = b.plainEnd(off, lineEnd, false)
+	}
+
+	colon := keyEnd
+	for colon < lineEnd && (b.src[colon] == ' ' || b.src[colon] == '\t') {
+		colon++
+	}
+
+	if colon >= lineEnd || b.src[colon] != ':' || (colon+1 < lineEnd && !isBlank(b.src[colon+1])) {
+		return 0, 0, false
+	}
+
+	value := colon + 1
+	for value < lineEnd && isBlank(b.src[value]) {
+		value++
+	}
+
+	return keyEnd, value, true
+}
+
+// frame returns the block collection an entry in column indent belongs to,
+// opening it when the entry is its first one.
+func (b *blockIndexer) frame(indent int, seq bool, off int) *blockFrame {
+	if len(b.frames) > 0 && b.top().indent == indent && b.top().seq == seq {
+		b.hasPending = false
+		return b.top()
+	}
+
+	pointer := ""
+	if b.hasPending && b.pendingIndent <= indent {
+		pointer = b.pending
+	} else if len(b.frames) > 0 {
+		pointer = b.top().pointer
+	}
+	b.hasPending = false
+
+	if node, ok := b.nodes[pointer]; ok && pointer != "" {
+		node.valueStart, node.valueEnd = off, off
+	}
+
+	frame := &blockFrame{indent: indent, pointer: pointer, seq: seq}
+	b.frames = append(b.frames, frame)
+
+	return frame
+}
+
+func (b *blockIndexer) top() *blockFrame {
+	return b.frames[len(b.frames)-1]
+}
+
+// extend moves the end of the node at pointer to end.
+func (b *blockIndexer) extend(pointer string, end int) {
+	if node, ok := b.nodes[pointer]; ok && end > node.valueEnd {
+		node.valueEnd = end
+	}
+
+	b.extendFrames(end)
+}
+
+// extendFrames moves the end of every open collection to end.
+func (b *blockIndexer) extendFrames(end int) {
+	for _, frame := range b.frames {
+		if frame.pointer == "" {
+			continue
+		}
+
+		if node, ok := b.nodes[frame.pointer]; ok && end > node.valueEnd {
+			node.valueEnd = end
+		}
+	}
+}
+
+// indentOf returns the indentation of the line holding off.
+func (b *blockIndexer) indentOf(off int) int {
+	line := bytes.LastIndexByte(b.src[:off], '\n') + 1
+
+	indent := 0
+	for line+indent < off && b.src[line+indent] == ' ' {
+		indent++
+	}
+
+	return indent
+}
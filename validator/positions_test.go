@@ -0,0 +1,146 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"testing"
+)
+
+const positionsYAML = `# nodes of the cluster
+name: demo   # trailing comment
+"quoted key": 'x'
+nodes:
+- name: a
+  cidr: 10.0.0.0/33
+  tags: [one, "two", {k: v}]
+-   name: b
+    labels:
+      app: web
+list:
+  - - 1
+    - 2
+script: |
+  echo hi
+  echo there
+after: plain
+  continued
+empty:
+items:
+- x
+last: {"a": [1,
+  2]}
+`
+
+const positionsJSON = `{
+  "a": {"b": [1, 2.5, "x\"y"]},
+  "c/d": null
+}`
+
+func TestPositionIndex(t *testing.T) {
+	tests := []struct {
+		source  string
+		pointer string
+		key     bool
+		span    Span
+	}{
+		{positionsYAML, "", false, Span{2, 1, 23, 6}},
+		{positionsYAML, "/name", false, Span{2, 7, 2, 11}},
+		{positionsYAML, "/name", true, Span{2, 1, 2, 5}},
+		{positionsYAML, "/quoted key", true, Span{3, 1, 3, 13}},
+		{positionsYAML, "/quoted key", false, Span{3, 15, 3, 18}},
+		{positionsYAML, "/nodes/0/cidr", false, Span{6, 9, 6, 20}},
+		{positionsYAML, "/nodes/0/tags/1", false, Span{7, 15, 7, 20}},
+		{positionsYAML, "/nodes/0/tags/2/k", true, Span{7, 23, 7, 24}},
+		{positionsYAML, "/nodes/1/name", false, Span{8, 11, 8, 12}},
+		{positionsYAML, "/nodes/1/labels/app", false, Span{10, 12, 10, 15}},
+		{positionsYAML, "/list/0/1", false, Span{13, 7, 13, 8}},
+		{positionsYAML, "/script", false, Span{14, 9, 16, 13}},
+		{positionsYAML, "/after", false, Span{17, 8, 18, 12}},
+		{positionsYAML, "/empty", false, Span{19, 1, 19, 6}},
+		{positionsYAML, "/items/0", false, Span{21, 3, 21, 4}},
+		{positionsYAML, "/last/a/1", false, Span{23, 3, 23, 4}},
+		// unknown nodes fall back to their closest ancestor
+		{positionsYAML, "/nodes/1/labels/tier", true, Span{10, 7, 10, 15}},
+		{positionsJSON, "", false, Span{1, 1, 4, 2}},
+		{positionsJSON, "/a", true, Span{2, 3, 2, 6}},
+		{positionsJSON, "/a/b/2", false, Span{2, 23, 2, 29}},
+		{positionsJSON, "/c~1d", false, Span{3, 10, 3, 14}},
+	}
+
+	for _, test := range tests {
+		span, ok := newPositionIndex([]byte(test.source)).lookup(test.pointer, test.key)
+		if !ok || span != test.span {
+			t.Errorf("%q (key %v): expected %v, got %v (found %v)", test.pointer, test.key, test.span, span, ok)
+		}
+	}
+}
+
+func TestExceptionPositions(t *testing.T) {
+	v := newTestValidator(t)
+
+	document := "name: a\nnetwork: 10.0.0.0\ncount: 0\n"
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]Span{
+		"(root).network": {2, 10, 2, 18},
+		"(root).count":   {3, 8, 3, 9},
+	}
+
+	for _, exception := range result.Exceptions {
+		span := Span{exception.Line, exception.Column, exception.EndLine, exception.EndColumn}
+		if exception.File != "config.yaml" || span != expected[exception.Path] {
+			t.Errorf("%s: expected %v in config.yaml, got %v in %q", exception.Path, expected[exception.Path], span, exception.File)
+		}
+	}
+
+	for _, exception := range result.Exceptions {
+		if exception.Path != "(root).network" {
+			continue
+		}
+
+		snippet := result.Snippet(exception)
+		if expected := "2 | network: 10.0.0.0\n  |          ^^^^^^^^\n"; snippet != expected {
+			t.Errorf("expected snippet\n%s\ngot\n%s", expected, snippet)
+		}
+	}
+}
+
+func TestErrorPointerKeys(t *testing.T) {
+	schema := []byte(`{"properties": {"a.b": {"properties": {"ok": {}}, "additionalProperties": false}}}`)
+
+	v, err := New(WithSchemaBytes("keys.json", schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "keys.yaml", []byte("a.b:\n  ok: 1\n  extra: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Exceptions) != 1 {
+		t.Fatalf("expected one exception, got %+v", result.Exceptions)
+	}
+
+	// the key of the unexpected property, found through a key holding a dot
+	if e := result.Exceptions[0]; e.Line != 3 || e.Column != 3 || e.EndColumn != 8 {
+		t.Errorf("expected the extra key at 3:3-8, got %+v", e)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The reference keywords check the integrity of a document whose sections
+// name one another:
+//
+//	x-ref-to     the instance is one of the values a selector picks
+//	             elsewhere in the document, e.g. the name of a subnet
+//	x-unique-by  no two items of the array instance have the same value at
+//	             the relative JSON Pointer from each item, e.g. "0/name";
+//	             true compares the items themselves
+//
+// A selector starts at the root of the document, $, or a number of levels
+// above the instance, as relative JSON Pointers do, and goes on with
+// JSONPath steps: .name or ['name'] for a member, [n] for an item, and .*
+// or [*] for all members or items. "6.providerConfig.subnet[*].name"
+// picks the names of the subnets of the object six levels up.
+func init() {
+	extensionKeywords["x-ref-to"] = extensionKeyword{compile: compileSelector, check: checkRefTo}
+	extensionKeywords["x-unique-by"] = extensionKeyword{compile: compileItemPointer, check: checkUniqueBy}
+}
+
+// selector picks values of a document.
+type selector struct {
+	// up is the number of levels above the instance the selector starts
+	// at, or -1 for the root of the document.
+	up int
+
+	steps []selectorStep
+}
+
+// selectorStep is a member name or item index to follow, or all of them.
+type selectorStep struct {
+	name string
+	all  bool
+}
+
+// parseSelector parses a selector such as $.clusters[*].name.
+func parseSelector(s string) (selector, error) {
+	var sel selector
+
+	rest := s
+	switch {
+	case strings.HasPrefix(s, "$"):
+		sel.up, rest = -1, s[1:]
+	default:
+		digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+		if digits == 0 || digits > 1 && s[0] == '0' {
+			return sel, fmt.Errorf("%q starts neither with $ nor with a number of levels", s)
+		}
+		sel.up, _ = strconv.Atoi(s[:digits])
+		rest = s[digits:]
+	}
+
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return sel, fmt.Errorf("empty member name in %q", s)
+			}
+			sel.steps = append(sel.steps, selectorStep{name: name, all: name == "*"})
+			rest = rest[end+1:]
+
+		case strings.HasPrefix(rest, "['"), strings.HasPrefix(rest, `["`):
+			end := strings.Index(rest[2:], rest[1:2]+"]")
+			if end < 0 {
+				return sel, fmt.Errorf("unterminated member name in %q", s)
+			}
+			sel.steps = append(sel.steps, selectorStep{name: rest[2 : end+2]})
+			rest = rest[end+4:]
+
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return sel, fmt.Errorf("unterminated index in %q", s)
+			}
+			index := rest[1:end]
+			if index != "*" {
+				if _, err := strconv.ParseUint(index, 10, 0); err != nil {
+					return sel, fmt.Errorf("invalid index %q in %q", index, s)
+				}
+			}
+			sel.steps = append(sel.steps, selectorStep{name: index, all: index == "*"})
+			rest = rest[end+1:]
+
+		default:
+			return sel, fmt.Errorf("unexpected %q in %q", rest, s)
+		}
+	}
+
+	return sel, nil
+}
+
+// base returns the pointer the selector starts at from the instance at
+// pointer.
+func (sel selector) base(pointer string) (string, bool) {
+	if sel.up < 0 {
+		return "", true
+	}
+
+	return dataPointer(pointer, strconv.Itoa(sel.up))
+}
+
+// location describes the values the selector picks from base as a JSON
+// Pointer, with * for the wildcard steps.
+func (sel selector) location(base string) string {
+	for _, step := range sel.steps {
+		base = appendPointer(base, step.name)
+	}
+
+	return base
+}
+
+// selected is a value picked by a selector.
+type selected struct {
+	pointer string
+	value   interface{}
+}
+
+// apply returns the values of document the selector picks from base, in
+// document order.
+func (sel selector) apply(document interface{}, base string) []selected {
+	node, ok := resolvePointer(document, base)
+	if !ok {
+		return nil
+	}
+
+	current := []selected{{pointer: base, value: node}}
+
+	for _, step := range sel.steps {
+		var next []selected
+
+		for _, s := range current {
+			switch n := s.value.(type) {
+			case map[string]interface{}:
+				if !step.all {
+					if child, ok := n[step.name]; ok {
+						next = append(next, selected{appendPointer(s.pointer, step.name), child})
+					}
+					continue
+				}
+
+				names := make([]string, 0, len(n))
+				for name := range n {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+
+				for _, name := range names {
+					next = append(next, selected{appendPointer(s.pointer, name), n[name]})
+				}
+
+			case []interface{}:
+				for i, item := range n {
+					if step.all || step.name == strconv.Itoa(i) {
+						next = append(next, selected{appendPointer(s.pointer, strconv.Itoa(i)), item})
+					}
+				}
+			}
+		}
+
+		current = next
+	}
+
+	return current
+}
+
+// compileSelector accepts a selector.
+func compileSelector(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a selector, got %v", value)
+	}
+
+	_, err := parseSelector(s)
+
+	return err
+}
+
+// valueKey returns a string standing for a JSON value, equal for equal
+// values: numbers are compared by value and objects whatever the order of
+// their members.
+func valueKey(value interface{}) string {
+	if n, ok := value.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return "number:" + strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+
+	data, _ := json.Marshal(value)
+
+	return jsonType(value) + ":" + string(data)
+}
+
+func checkRefTo(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return nil
+	}
+
+	sel, err := parseSelector(p.node["x-ref-to"].(string))
+	if err != nil {
+		return nil
+	}
+
+	base, ok := sel.base(pointer)
+	if !ok {
+		return nil
+	}
+
+	// a selector picking nothing, e.g. in a section copied outside of the
+	// one it refers to, is not checked
+	candidates := sel.apply(r.document, base)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	key := valueKey(value)
+	for _, candidate := range candidates {
+		if valueKey(candidate.value) == key {
+			return nil
+		}
+	}
+
+	return []formatFailure{{
+		owner:     p,
+		keyword:   "x-ref-to",
+		pointer:   pointer,
+		value:     value,
+		errorType: "ref_to",
+		text:      "Is not one of the values at {{.location}}",
+		params:    map[string]interface{}{"location": sel.location(base), "selector": p.node["x-ref-to"]},
+	}}
+}
+
+func checkUniqueBy(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	ref := itemPointer(p.node["x-unique-by"])
+
+	var failures []formatFailure
+	first := map[string]string{}
+
+	for i := range items {
+		at, ok := dataPointer(appendPointer(pointer, strconv.Itoa(i)), ref)
+		if !ok {
+			continue
+		}
+
+		key, ok := resolvePointer(r.document, at)
+		if !ok {
+			continue
+		}
+
+		k := valueKey(key)
+		if other, ok := first[k]; ok {
+			failures = append(failures, formatFailure{
+				owner:     p,
+				keyword:   "x-unique-by",
+				pointer:   at,
+				value:     key,
+				errorType: "unique_by",
+				text:      "Duplicates the value at {{.location}}",
+				params:    map[string]interface{}{"location": other},
+			})
+			continue
+		}
+		first[k] = at
+	}
+
+	return failures
+}
@@ -0,0 +1,146 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSelectors(t *testing.T) {
+	document := map[string]interface{}{
+		"clusters": []interface{}{
+			map[string]interface{}{"name": "a", "zones": []interface{}{
+				map[string]interface{}{"name": "z1"}, map[string]interface{}{"name": "z2"},
+			}},
+			map[string]interface{}{"name": "b", "*": "star"},
+		},
+	}
+
+	tests := []struct {
+		selector string
+		pointer  string
+		selected []string
+	}{
+		{"$.clusters[*].name", "", []string{"/clusters/0/name", "/clusters/1/name"}},
+		{"$['clusters'][1].name", "", []string{"/clusters/1/name"}},
+		{"$.clusters[0].zones[*].name", "", []string{"/clusters/0/zones/0/name", "/clusters/0/zones/1/name"}},
+		{"$.clusters[1].*", "", []string{"/clusters/1/*", "/clusters/1/name"}},
+		{"$.clusters[1]['*']", "", []string{"/clusters/1/*"}},
+		{"3.zones[*].name", "/clusters/0/zones/1/name", []string{"/clusters/0/zones/0/name", "/clusters/0/zones/1/name"}},
+		{"0", "/clusters/1/name", []string{"/clusters/1/name"}},
+		{"$.missing[*].name", "", nil},
+		{"9.name", "/clusters", nil},
+	}
+
+	for _, test := range tests {
+		sel, err := parseSelector(test.selector)
+		if err != nil {
+			t.Errorf("%s: %v", test.selector, err)
+			continue
+		}
+
+		var pointers []string
+		if base, ok := sel.base(test.pointer); ok {
+			for _, s := range sel.apply(document, base) {
+				pointers = append(pointers, s.pointer)
+			}
+		}
+
+		if strings.Join(pointers, " ") != strings.Join(test.selected, " ") {
+			t.Errorf("%s from %q: expected %v, got %v", test.selector, test.pointer, test.selected, pointers)
+		}
+	}
+
+	for _, selector := range []string{"", "clusters[*]", "$.", "$[x]", "$['name", "$.a[1", "01.name", "$name"} {
+		if _, err := parseSelector(selector); err == nil {
+			t.Errorf("%q: expected an invalid selector", selector)
+		}
+	}
+}
+
+func TestReferenceKeywords(t *testing.T) {
+	v, err := New(WithSchemaBytes("references.json", []byte(`{
+		"properties": {
+			"clusters": {
+				"items": {
+					"properties": {
+						"nodePools": {
+							"items": {
+								"properties": {
+									"zones": { "items": { "x-ref-to": "4.subnets[*].name" } }
+								}
+							},
+							"x-unique-by": "0/name"
+						}
+					}
+				},
+				"x-unique-by": "0/name"
+			},
+			"mounts": { "items": { "properties": { "device": { "x-ref-to": "$.storage[*].opts.device_name" } } } },
+			"ports": { "x-unique-by": true }
+		}
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		errors   []string
+	}{
+		{`{"clusters": [{"name": "a", "subnets": [{"name": "z1"}], "nodePools": [{"name": "p", "zones": ["z1"]}]},
+		                {"name": "b", "subnets": [{"name": "z2"}], "nodePools": [{"name": "p", "zones": ["z2"]}]}],
+		   "storage": [{"opts": {"device_name": "sdf"}}], "mounts": [{"device": "sdf"}], "ports": [80, 443]}`, nil},
+		{`{"clusters": [{"name": "a", "subnets": [{"name": "z1"}], "nodePools": [{"name": "p", "zones": ["z1", "z2"]}]}]}`,
+			[]string{"ref_to clusters.0.nodePools.0.zones.1: Is not one of the values at /clusters/0/subnets/*/name"}},
+		{`{"clusters": [{"name": "a", "nodePools": [{"name": "p"}, {"name": "q"}, {"name": "p"}]}, {"name": "a"}]}`,
+			[]string{
+				"unique_by clusters.0.nodePools.2.name: Duplicates the value at /clusters/0/nodePools/0/name",
+				"unique_by clusters.1.name: Duplicates the value at /clusters/0/name",
+			}},
+		{`{"storage": [{"opts": {"device_name": "sdf"}}], "mounts": [{"device": "sdg"}]}`,
+			[]string{"ref_to mounts.0.device: Is not one of the values at /storage/*/opts/device_name"}},
+		{`{"ports": [80, 443, 80.0]}`, []string{"unique_by ports.2: Duplicates the value at /ports/0"}},
+
+		// nothing to refer to, or nothing to compare by, is not checked
+		{`{"clusters": [{"name": "a", "nodePools": [{"name": "p", "zones": ["z1"]}, {"count": 1}, {"count": 2}]}]}`, nil},
+		{`{"mounts": [{"device": "sdg"}]}`, nil},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "config.json", []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: %v", test.document, err)
+			continue
+		}
+
+		var errors []string
+		for _, e := range result.Exceptions {
+			errors = append(errors, e.Type+" "+e.ErrorString)
+		}
+
+		if strings.Join(errors, "\n") != strings.Join(test.errors, "\n") || result.IsValid != (len(test.errors) == 0) {
+			t.Errorf("%s:\nexpected %v\ngot      %v", test.document, test.errors, errors)
+		}
+	}
+
+	for _, schema := range []string{`{"x-ref-to": "subnets[*]"}`, `{"x-unique-by": "/name"}`, `{"x-unique-by": false}`} {
+		if _, err := New(WithSchemaBytes("bad.json", []byte(schema))); err == nil {
+			t.Errorf("%s: expected the schema to be refused", schema)
+		}
+	}
+}
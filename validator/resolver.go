@@ -0,0 +1,525 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonreference"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// refScheme is the scheme of the internal URIs every $ref is rewritten to.
+// Each distinct $ref target gets one fragment-free URI, which sidesteps the
+// gojsonschema reference cache never matching pointer fragments and
+// recursing forever on recursive definitions.
+const refScheme = "jsonsvalidator"
+
+// RefError reports a $ref that cannot be resolved, or that only leads back
+// to itself through other $refs.
+type RefError struct {
+	// Ref is the $ref as written in the schema.
+	Ref string
+
+	// Location is the schema object holding the $ref, as "uri#pointer".
+	Location string
+
+	// Line and Column locate the $ref in the source of its schema
+	// document. They are zero when the position is unknown.
+	Line   int
+	Column int
+
+	// Reason tells why the $ref failed.
+	Reason string
+
+	// Chain lists the $ref sites followed from the root schema to reach
+	// Location, Location included.
+	Chain []string
+}
+
+func (e *RefError) Error() string {
+	return fmt.Sprintf("invalid $ref %q at %s%s: %s (reached via %s)",
+		e.Ref, e.Location, lineColumn(e.Line, e.Column), e.Reason, strings.Join(e.Chain, " -> "))
+}
+
+// lineColumn formats a source position for an error message, or returns ""
+// when it is unknown.
+func lineColumn(line int, column int) string {
+	if line == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (line %d, column %d)", line, column)
+}
+
+// location addresses a value inside a schema document.
+type location struct {
+	document string
+	pointer  string
+}
+
+func (l location) String() string {
+	return (&url.URL{Opaque: l.document, Fragment: l.pointer}).String()
+}
+
+// refSite is a schema object holding a $ref.
+type refSite struct {
+	at     location
+	node   map[string]interface{}
+	base   *url.URL
+	ref    string
+	target location
+}
+
+// schemaBundle holds a root schema and every document reachable through its
+// $refs. Loading it resolves each $ref once, against the base URI in effect
+// where it is written, honouring "id" (draft 4) and "$id" along the way.
+// The bundle then serves the documents to gojsonschema as a
+// gojsonschema.JSONLoaderFactory.
+type schemaBundle struct {
+	fetcher *schemaFetcher
+	root    string
+
+	documents map[string]interface{}
+	positions map[string]*positionIndex
+	loadedBy  map[string]*refSite
+	rootIDs   map[string]*url.URL
+	ids       map[string]location
+	sites     []*refSite
+	siteAt    map[location]*refSite
+
+	targets  map[location]string
+	internal map[string]location
+
+	// formats, extensions, data and checked are set by stripFormats.
+	formats    map[location]string
+	extensions map[location][]string
+	data       map[location]map[string]interface{}
+	checked    map[location]bool
+}
+
+// loadSchemaBundle loads the root schema at rootURI, using data as its
+// contents when given, and everything it references.
+func loadSchemaBundle(fetcher *schemaFetcher, rootURI string, data []byte) (*schemaBundle, error) {
+	b := &schemaBundle{
+		fetcher:   fetcher,
+		root:      rootURI,
+		documents: map[string]interface{}{},
+		positions: map[string]*positionIndex{},
+		loadedBy:  map[string]*refSite{},
+		rootIDs:   map[string]*url.URL{},
+		ids:       map[string]location{},
+		siteAt:    map[location]*refSite{},
+		targets:   map[location]string{},
+		internal:  map[string]location{},
+	}
+
+	var document interface{}
+	var positions *positionIndex
+	var err error
+
+	if data != nil {
+		document, positions, err = decodeSchema("schema", rootURI, data)
+	} else {
+		document, positions, err = fetcher.load(rootURI)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.add(rootURI, document, positions, nil); err != nil {
+		return nil, err
+	}
+
+	// resolving a $ref may load documents holding more $refs
+	for i := 0; i < len(b.sites); i++ {
+		if err := b.resolve(b.sites[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.checkCycles(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// add records document as retrieved from uri, along with the index of its
+// source positions, and scans it for ids and $refs.
+func (b *schemaBundle) add(uri string, document interface{}, positions *positionIndex, loadedBy *refSite) error {
+	base, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+
+	b.documents[uri] = document
+	b.positions[uri] = positions
+	b.loadedBy[uri] = loadedBy
+	b.ids[uri] = location{document: uri}
+
+	if root, ok := document.(map[string]interface{}); ok {
+		if id, ok := declaredID(root); ok {
+			if idURL, err := url.Parse(id); err == nil {
+				b.rootIDs[uri] = stripFragment(base.ResolveReference(idURL))
+			}
+		}
+	}
+
+	b.scan(uri, document, "", base)
+
+	return nil
+}
+
+// keywords whose values are instance data rather than schemas.
+var dataKeywords = map[string]bool{
+	"enum":     true,
+	"const":    true,
+	"default":  true,
+	"examples": true,
+}
+
+// schemaChildren calls visit, in a stable order, with the values of the
+// schema object n found at pointer that may hold schemas: every keyword but
+// those holding instance data, and the members of the keywords mapping names
+// to schemas, whatever their names, so that a property called default is
+// walked like any other.
+func schemaChildren(n map[string]interface{}, pointer string, visit func(pointer string, value interface{})) {
+	for _, key := range sortedKeys(n) {
+		if dataKeywords[key] {
+			continue
+		}
+
+		members, ok := n[key].(map[string]interface{})
+		if !ok || !isSchemaMapKeyword(key) {
+			visit(appendPointer(pointer, key), n[key])
+			continue
+		}
+
+		for _, name := range sortedKeys(members) {
+			visit(appendPointer(appendPointer(pointer, key), name), members[name])
+		}
+	}
+}
+
+// isSchemaMapKeyword reports whether the members of keyword are schemas.
+func isSchemaMapKeyword(keyword string) bool {
+	for _, k := range schemaMapKeywords {
+		if k == keyword {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedKeys returns the keys of object in order.
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// scan walks node, found at pointer inside the document retrieved from uri,
+// registering the base URIs declared by ids and collecting $refs.
+func (b *schemaBundle) scan(uri string, node interface{}, pointer string, base *url.URL) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if id, ok := declaredID(n); ok {
+			if idURL, err := url.Parse(id); err == nil {
+				base = base.ResolveReference(idURL)
+				b.ids[base.String()] = location{document: uri, pointer: pointer}
+
+				base = stripFragment(base)
+				if _, known := b.ids[base.String()]; !known {
+					b.ids[base.String()] = location{document: uri, pointer: pointer}
+				}
+			}
+		}
+
+		if ref, ok := n["$ref"].(string); ok {
+			site := &refSite{
+				at:   location{document: uri, pointer: pointer},
+				node: n,
+				base: base,
+				ref:  ref,
+			}
+
+			b.sites = append(b.sites, site)
+			b.siteAt[site.at] = site
+		}
+
+		schemaChildren(n, pointer, func(pointer string, value interface{}) {
+			b.scan(uri, value, pointer, base)
+		})
+
+	case []interface{}:
+		for i, item := range n {
+			b.scan(uri, item, appendPointer(pointer, strconv.Itoa(i)), base)
+		}
+	}
+}
+
+// resolve finds the target of site and rewrites its $ref to the target's
+// internal URI.
+func (b *schemaBundle) resolve(site *refSite) error {
+	refURL, err := url.Parse(site.ref)
+	if err != nil {
+		return b.refError(site, err.Error())
+	}
+
+	target := site.base.ResolveReference(refURL)
+	fragment := target.Fragment
+
+	var at location
+
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		// a plain name fragment, declared by an id such as "#node"
+		found, ok := b.ids[target.String()]
+		if !ok {
+			return b.refError(site, fmt.Sprintf("no schema declares the id %q", target))
+		}
+		at = found
+	} else {
+		found, err := b.document(site, stripFragment(target).String(), refURL)
+		if err != nil {
+			return err
+		}
+		at = location{document: found.document, pointer: found.pointer + fragment}
+	}
+
+	if _, ok := resolvePointer(b.documents[at.document], at.pointer); !ok {
+		return b.refError(site, fmt.Sprintf("%s does not exist", at))
+	}
+
+	site.target = at
+	site.node["$ref"] = b.internalURI(at)
+
+	return nil
+}
+
+// document returns the location of the schema document uri, loading it when
+// needed. When uri comes from an id that is not where the document can be
+// retrieved, e.g. a published http:// id of a schema read from disk, the
+// $ref is first tried against the location the referring document was read
+// from.
+func (b *schemaBundle) document(site *refSite, uri string, refURL *url.URL) (location, error) {
+	if at, ok := b.ids[uri]; ok {
+		return at, nil
+	}
+
+	var candidates []string
+
+	if local := b.retrievalURI(site.at.document, uri); local != "" && local != uri {
+		candidates = append(candidates, local)
+	}
+	candidates = append(candidates, uri)
+
+	var failures []string
+
+	for _, candidate := range candidates {
+		if at, ok := b.ids[candidate]; ok {
+			return at, nil
+		}
+
+		document, positions, err := b.fetcher.load(candidate)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+
+		if err := b.add(candidate, document, positions, site); err != nil {
+			return location{}, err
+		}
+
+		return location{document: candidate}, nil
+	}
+
+	return location{}, b.refError(site, strings.Join(failures, "; "))
+}
+
+// retrievalURI maps uri, resolved against the ids declared in document, to
+// where it would be retrieved relative to the location document itself was
+// retrieved from. It returns "" when uri lies outside the id's tree.
+func (b *schemaBundle) retrievalURI(document string, uri string) string {
+	retrieval, err := url.Parse(document)
+	if err != nil {
+		return ""
+	}
+
+	target, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+
+	idBase, ok := b.rootIDs[document]
+	if !ok {
+		return stripFragment(retrieval.ResolveReference(target)).String()
+	}
+
+	if target.Scheme != idBase.Scheme || target.Host != idBase.Host {
+		return ""
+	}
+
+	rel, err := filepath.Rel(path.Dir(idBase.Path), target.Path)
+	if err != nil {
+		return ""
+	}
+
+	return retrieval.ResolveReference(&url.URL{Path: filepath.ToSlash(rel)}).String()
+}
+
+// checkCycles reports $refs that only lead to other $refs and eventually
+// back to themselves; they would send validation into endless recursion.
+func (b *schemaBundle) checkCycles() error {
+	for _, site := range b.sites {
+		seen := map[*refSite]bool{}
+
+		for next := site; next != nil; next = b.siteAt[next.target] {
+			if seen[next] {
+				if next == site {
+					return b.refError(site, "the $ref only leads back to itself")
+				}
+				break
+			}
+			seen[next] = true
+		}
+	}
+
+	return nil
+}
+
+// refError builds the RefError for site, with the chain of $refs followed
+// from the root schema to reach it.
+func (b *schemaBundle) refError(site *refSite, reason string) *RefError {
+	var chain []string
+
+	for s := site; s != nil; s = b.loadedBy[s.at.document] {
+		chain = append([]string{s.at.String()}, chain...)
+	}
+
+	span, _ := b.position(location{document: site.at.document, pointer: appendPointer(site.at.pointer, "$ref")}, true)
+
+	return &RefError{
+		Ref:      site.ref,
+		Location: site.at.String(),
+		Line:     span.Line,
+		Column:   span.Column,
+		Reason:   reason,
+		Chain:    chain,
+	}
+}
+
+// position returns where the value at is written in the source of its
+// document, or where its key is when key is set.
+func (b *schemaBundle) position(at location, key bool) (Span, bool) {
+	return b.positions[at.document].lookup(at.pointer, key)
+}
+
+// internalURI returns the internal URI standing for the schema at target.
+func (b *schemaBundle) internalURI(target location) string {
+	if uri, ok := b.targets[target]; ok {
+		return uri
+	}
+
+	uri := fmt.Sprintf("%s://ref/%d", refScheme, len(b.targets))
+	b.targets[target] = uri
+	b.internal[uri] = target
+
+	return uri
+}
+
+// New returns a loader for uri, either a document of the bundle or an
+// internal $ref URI. It implements gojsonschema.JSONLoaderFactory.
+func (b *schemaBundle) New(uri string) gojsonschema.JSONLoader {
+	return &bundleLoader{bundle: b, source: uri}
+}
+
+// bundleLoader is the gojsonschema.JSONLoader for one schema of a bundle.
+type bundleLoader struct {
+	bundle *schemaBundle
+	source string
+}
+
+// JsonSource returns the URI of the schema.
+func (l *bundleLoader) JsonSource() interface{} {
+	return l.source
+}
+
+// JsonReference returns the URI of the schema as a reference.
+func (l *bundleLoader) JsonReference() (gojsonreference.JsonReference, error) {
+	return gojsonreference.NewJsonReference(l.source)
+}
+
+// LoaderFactory returns the bundle.
+func (l *bundleLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return l.bundle
+}
+
+// LoadJSON returns the already loaded schema.
+func (l *bundleLoader) LoadJSON() (interface{}, error) {
+	uri := l.source
+	if u, err := url.Parse(uri); err == nil {
+		uri = stripFragment(u).String()
+	}
+
+	at, ok := l.bundle.internal[uri]
+	if !ok {
+		at = location{document: uri}
+	}
+
+	document, ok := l.bundle.documents[at.document]
+	if !ok {
+		return nil, fmt.Errorf("schema %s was not loaded", l.source)
+	}
+
+	node, ok := resolvePointer(document, at.pointer)
+	if !ok {
+		return nil, fmt.Errorf("schema %s does not exist", l.source)
+	}
+
+	return node, nil
+}
+
+// declaredID returns the base URI declared by a schema object, through "$id" or
+// the draft 4 "id".
+func declaredID(node map[string]interface{}) (string, bool) {
+	for _, key := range []string{"$id", "id"} {
+		if id, ok := node[key].(string); ok {
+			return id, true
+		}
+	}
+
+	return "", false
+}
+
+// stripFragment returns a copy of u without its fragment.
+func stripFragment(u *url.URL) *url.URL {
+	stripped := *u
+	stripped.Fragment = ""
+
+	return &stripped
+}
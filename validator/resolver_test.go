@@ -0,0 +1,224 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSchemas writes files, keyed by relative path, into a new temporary
+// directory and returns it.
+func writeSchemas(t testing.TB, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "jsonsvalidator")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestRefResolution(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"root.json": `{
+			"id": "http://example.com/schemas/root.json",
+			"properties": {
+				"tree":    { "$ref": "tree.json" },
+				"leaf":    { "id": "sub/", "properties": { "value": { "$ref": "leaf.json" } } },
+				"address": { "$ref": "#address" },
+				"default": { "$ref": "defs.json#/port", "examples": [ { "$ref": "missing.json" } ] }
+			},
+			"definitions": {
+				"address": { "id": "#address", "required": [ "street" ] }
+			}
+		}`,
+		"defs.json": `{ "port": { "type": "integer" } }`,
+		"tree.json": `{
+			"definitions": {
+				"node": {
+					"properties": {
+						"children": { "items": { "$ref": "#/definitions/node" }, "type": "array" },
+						"name": { "type": "string" }
+					},
+					"type": "object"
+				}
+			},
+			"$ref": "#/definitions/node"
+		}`,
+		"sub/leaf.json": `{ "type": "integer" }`,
+	})
+	defer os.RemoveAll(dir)
+
+	v, err := New(WithSchemaFile(filepath.Join(dir, "root.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		errors   []string
+	}{
+		{`{"tree": {"children": [{"name": "a", "children": [{"name": "b"}]}]}}`, nil},
+		{`{"tree": {"children": [{"children": [{"name": 3}]}]}}`, []string{"(root).tree.children.0.children.0.name"}},
+		{`{"leaf": {"value": "three"}}`, []string{"(root).leaf.value"}},
+		{`{"address": {}}`, []string{"(root).address"}},
+		{`{"default": "http"}`, []string{"(root).default"}},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "test", []byte(test.document))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var paths []string
+		for _, e := range result.Exceptions {
+			paths = append(paths, e.Path)
+		}
+
+		if strings.Join(paths, ",") != strings.Join(test.errors, ",") {
+			t.Errorf("%s: expected errors at %v, got %+v", test.document, test.errors, result.Exceptions)
+		}
+	}
+}
+
+func TestRefErrors(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"missing_file.json":    `{ "properties": { "a": { "$ref": "sections/a.json" } } }`,
+		"sections/a.json":      `{ "properties": { "b": { "$ref": "b.json#/definitions/b" } } }`,
+		"missing_pointer.json": `{ "properties": { "a": { "$ref": "#/definitions/nope" } } }`,
+		"cycle.json": `{
+			"definitions": {
+				"a": { "$ref": "#/definitions/b" },
+				"b": { "$ref": "#/definitions/a" }
+			},
+			"properties": { "x": { "$ref": "#/definitions/a" } }
+		}`,
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		schema string
+		reason string
+		chain  int
+		line   int
+	}{
+		{"missing_file.json", "b.json: no such file or directory", 2, 1},
+		{"missing_pointer.json", "#/definitions/nope does not exist", 1, 1},
+		{"cycle.json", "only leads back to itself", 1, 3},
+	}
+
+	for _, test := range tests {
+		_, err := New(WithSchemaFile(filepath.Join(dir, test.schema)))
+
+		schemaErr, ok := err.(*SchemaError)
+		if !ok {
+			t.Errorf("%s: expected a *SchemaError, got %v", test.schema, err)
+			continue
+		}
+
+		refErr, ok := schemaErr.Err.(*RefError)
+		if !ok {
+			t.Errorf("%s: expected a *RefError, got %v", test.schema, schemaErr.Err)
+			continue
+		}
+
+		if !strings.Contains(refErr.Reason, test.reason) || len(refErr.Chain) != test.chain {
+			t.Errorf("%s: expected %q with a chain of %d, got %v", test.schema, test.reason, test.chain, refErr)
+		}
+
+		if refErr.Line != test.line {
+			t.Errorf("%s: expected the $ref on line %d, got %v", test.schema, test.line, refErr)
+		}
+	}
+}
+
+func TestYAMLSchemas(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"root.yaml": `# hand-maintained
+type: object
+required: [name]
+properties:
+  name:
+    type: string  # the cluster name
+  nodes:
+    $ref: nodes.yml
+  region:
+    $ref: "regions.json#/definitions/region"
+`,
+		"nodes.yml": `type: array
+items:
+  type: integer
+  minimum: 1
+`,
+		"regions.json": `{ "definitions": { "region": { "enum": [ "us-east", "us-west" ] } } }`,
+		"stream.yaml":  "type: object\n---\ntype: string\n",
+		"broken.yaml":  "type: object\nproperties: [\n",
+		"bad_ref.yaml": "properties:\n  a:\n    type: string\n  b:\n    $ref: '#/definitions/nope'\n",
+	})
+	defer os.RemoveAll(dir)
+
+	v, err := New(WithSchemaFile(filepath.Join(dir, "root.yaml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("name: a\nnodes: [1, 0]\nregion: eu-west\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pointers []string
+	for _, e := range result.Exceptions {
+		pointers = append(pointers, e.Pointer)
+	}
+
+	if strings.Join(pointers, ",") != "/nodes/1,/region" {
+		t.Errorf("expected errors at /nodes/1 and /region, got %+v", result.Exceptions)
+	}
+
+	tests := []struct {
+		schema string
+		err    string
+	}{
+		{"stream.yaml", "found 2 YAML documents"},
+		{"broken.yaml", "yaml: line 2"},
+		{"bad_ref.yaml", "#/properties/b (line 5, column 5)"},
+	}
+
+	for _, test := range tests {
+		_, err := New(WithSchemaFile(filepath.Join(dir, test.schema)))
+
+		if _, ok := err.(*SchemaError); !ok || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected a *SchemaError containing %q, got %v", test.schema, test.err, err)
+		}
+	}
+}
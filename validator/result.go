@@ -0,0 +1,302 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Versions of the JSON layout of a ValidatorResult, see Output.
+const (
+	// OutputV1 is the original layout. Each exception holds error_string,
+	// path and, when known, its position in the config. Results of a
+	// document from a YAML stream also hold its index and starting line.
+	OutputV1 = 1
+
+	// OutputV2 adds the version to the result. It also adds the error type,
+	// instance pointer, keyword locations, rejected value, keyword
+	// parameters and, for anyOf and oneOf with WithCauses, the errors of each
+	// branch to each exception, and the rule and severity to the exceptions
+	// of rules.
+	OutputV2 = 2
+
+	// LatestOutput is the newest output version.
+	LatestOutput = OutputV2
+)
+
+// ValidatorResult is the structure containing the validation results from JSON schema validation
+type ValidatorResult struct {
+	Version    int               `json:"version,omitempty"`
+	IsValid    bool              `json:"is_valid"`
+	Exceptions []ExceptionDetail `json:"exception"`
+	Config     string            `json:"config"`
+	Schema     string            `json:"schema"`
+
+	// Document is the index, from 0, of the validated document in its YAML
+	// stream, Documents the number of documents in the stream, and
+	// StartLine the line the document starts on.
+	Document  int `json:"document"`
+	Documents int `json:"documents,omitempty"`
+	StartLine int `json:"start_line,omitempty"`
+
+	// Source holds the document of a failed validation so that exceptions
+	// can be quoted with Snippet. It is empty for ValidateValue.
+	Source []byte `json:"-"`
+}
+
+// ExceptionDetail contains error messages and path. It is part of the ValidatorResult struct.
+// File, Line, Column, EndLine and EndColumn locate the offending node in the
+// config when it was read from YAML or JSON text. Lines and columns are
+// 1-based, columns count characters, and EndColumn is the column just past
+// the node.
+//
+// Exceptions raised by the schema also carry the machine-readable fields
+// below; Type is empty for exceptions added with AppendException.
+type ExceptionDetail struct {
+	ErrorString string `json:"error_string"`
+	Path        string `json:"path"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Column      int    `json:"column,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	EndColumn   int    `json:"end_column,omitempty"`
+
+	// Type is the gojsonschema error type, such as "required", "enum" or
+	// "string_gte".
+	Type string `json:"type,omitempty"`
+
+	// Pointer is the RFC 6901 JSON Pointer of the offending value. Errors
+	// about an unexpected property point at the property.
+	Pointer string `json:"pointer"`
+
+	// KeywordLocation is the path of schema keywords, $refs included, from
+	// the root schema to the failing keyword, as a JSON Pointer.
+	KeywordLocation string `json:"keyword_location,omitempty"`
+
+	// AbsoluteKeywordLocation is the URI of the failing keyword in the
+	// schema document declaring it.
+	AbsoluteKeywordLocation string `json:"absolute_keyword_location,omitempty"`
+
+	// Value is the offending value.
+	Value interface{} `json:"value"`
+
+	// Params holds the parameters of the failing keyword, such as the
+	// "min" of a minimum or the "property" missing for required.
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	// Rule is the id of the policy rule the value breaks, for exceptions of
+	// type "rule", and Severity its severity: error, warning or info.
+	// Exceptions without a severity are errors.
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity,omitempty"`
+
+	// Causes holds, for anyOf and oneOf errors, the errors of every branch
+	// the value fails, when the Validator was built WithCauses. The
+	// exceptions of the result only hold those of the branch closest to
+	// matching.
+	Causes []ExceptionDetail `json:"causes,omitempty"`
+
+	// units are the schemas evaluated from the root schema down to the
+	// failing keyword, see Structured.
+	units []outputLocation
+}
+
+// IsError tells whether e makes its document invalid, rather than being a
+// warning or note of a rule.
+func (e ExceptionDetail) IsError() bool {
+	return e.Severity == "" || e.Severity == SeverityError
+}
+
+// resultV1 is the OutputV1 layout of a ValidatorResult.
+type resultV1 struct {
+	IsValid    bool          `json:"is_valid"`
+	Exceptions []exceptionV1 `json:"exception"`
+	Config     string        `json:"config"`
+	Schema     string        `json:"schema"`
+	Document   *int          `json:"document,omitempty"`
+	StartLine  int           `json:"start_line,omitempty"`
+}
+
+// exceptionV1 is the OutputV1 layout of an ExceptionDetail.
+type exceptionV1 struct {
+	ErrorString string `json:"error_string"`
+	Path        string `json:"path"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line,omitempty"`
+	Column      int    `json:"column,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	EndColumn   int    `json:"end_column,omitempty"`
+}
+
+func (e *ExceptionDetail) setSpan(span Span) {
+	e.Line, e.Column = span.Line, span.Column
+	e.EndLine, e.EndColumn = span.EndLine, span.EndColumn
+}
+
+// NewResult returns an empty, not yet valid, result for the given config and schema.
+func NewResult(config string, schema string) *ValidatorResult {
+	return &ValidatorResult{
+		IsValid:    false,
+		Exceptions: []ExceptionDetail{},
+		Config:     config,
+		Schema:     schema,
+	}
+}
+
+// Output returns r laid out as the given output version, ready to be
+// marshalled to JSON.
+func (r *ValidatorResult) Output(version int) (interface{}, error) {
+	switch version {
+	case OutputV1:
+		out := resultV1{
+			IsValid:    r.IsValid,
+			Exceptions: make([]exceptionV1, 0, len(r.Exceptions)),
+			Config:     r.Config,
+			Schema:     r.Schema,
+		}
+
+		// only results from YAML streams tell their document apart
+		if r.Documents > 1 {
+			document := r.Document
+			out.Document, out.StartLine = &document, r.StartLine
+		}
+
+		for _, e := range r.Exceptions {
+			out.Exceptions = append(out.Exceptions, exceptionV1{
+				ErrorString: e.ErrorString,
+				Path:        e.Path,
+				File:        e.File,
+				Line:        e.Line,
+				Column:      e.Column,
+				EndLine:     e.EndLine,
+				EndColumn:   e.EndColumn,
+			})
+		}
+
+		return out, nil
+
+	case OutputV2:
+		out := *r
+		out.Version = OutputV2
+
+		return &out, nil
+	}
+
+	return nil, fmt.Errorf("unsupported output version %d; supported versions are %d to %d", version, OutputV1, LatestOutput)
+}
+
+// Err returns an *InvalidError when the document did not validate, and nil
+// otherwise.
+func (r *ValidatorResult) Err() error {
+	if r.IsValid {
+		return nil
+	}
+
+	return &InvalidError{Result: r}
+}
+
+// AppendException records err as an exception without a known path.
+func (r *ValidatorResult) AppendException(err error) {
+	r.AppendExceptionWithPath(err, "Not Reported")
+}
+
+// AppendExceptionWithPath records err as an exception found at path.
+func (r *ValidatorResult) AppendExceptionWithPath(err error, path string) {
+	r.appendExceptionMessage(err.Error(), path)
+}
+
+func (r *ValidatorResult) appendExceptionMessage(errMsg string, path string) {
+	exception := ExceptionDetail{
+		ErrorString: errMsg,
+		Path:        path,
+	}
+
+	r.Exceptions = append(r.Exceptions, exception)
+}
+
+// Snippet quotes the source lines of exception e, with a caret line under
+// the offending node:
+//
+//	12 |     cidr: 10.0.0.0/33
+//	   |           ^^^^^^^^^^^
+//
+// It returns an empty string when e has no position or the result no source.
+func (r *ValidatorResult) Snippet(e ExceptionDetail) string {
+	if e.Line == 0 || len(r.Source) == 0 {
+		return ""
+	}
+
+	lines := bytes.Split(r.Source, []byte("\n"))
+	if e.Line > len(lines) {
+		return ""
+	}
+
+	endLine := e.EndLine
+	if endLine < e.Line {
+		endLine = e.Line
+	}
+	if endLine > len(lines) {
+		endLine = len(lines)
+	}
+
+	// long nodes are cut short; the caret under the first line is enough
+	const maxLines = 5
+	if endLine-e.Line >= maxLines {
+		endLine = e.Line + maxLines - 1
+	}
+
+	width := len(strconv.Itoa(endLine))
+	gutter := strings.Repeat(" ", width)
+
+	var buf bytes.Buffer
+	for n := e.Line; n <= endLine; n++ {
+		text := strings.TrimRight(string(lines[n-1]), "\r")
+		fmt.Fprintf(&buf, "%*d | %s\n", width, n, expandTabs(text))
+
+		start, end := 1, utf8.RuneCountInString(text)+1
+		if n == e.Line {
+			start = e.Column
+		}
+		if n == e.EndLine {
+			end = e.EndColumn
+		}
+		if end <= start {
+			end = start + 1
+		}
+
+		indent := strings.Repeat(" ", start-1)
+		if n > e.Line {
+			indent = strings.Repeat(" ", leadingSpace(text))
+			start = len(indent) + 1
+		}
+
+		fmt.Fprintf(&buf, "%s | %s%s\n", gutter, indent, strings.Repeat("^", end-start))
+	}
+
+	return buf.String()
+}
+
+// expandTabs replaces tabs with a single space so carets stay aligned.
+func expandTabs(s string) string {
+	return strings.Replace(s, "\t", " ", -1)
+}
+
+func leadingSpace(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
@@ -0,0 +1,72 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOutputVersions(t *testing.T) {
+	v := newTestValidator(t)
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("name: a\ncount: 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version  int
+		contains []string
+		excludes []string
+	}{
+		{OutputV1, []string{`"error_string"`, `"line":2`}, []string{`"version"`, `"type"`, `"pointer"`}},
+		{OutputV2, []string{`"version":2`, `"type":"number_gte"`, `"pointer":"/count"`, `"value":0`, `"params":{"min":1}`}, nil},
+	}
+
+	for _, test := range tests {
+		output, err := result.Output(test.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, s := range test.contains {
+			if !strings.Contains(string(data), s) {
+				t.Errorf("version %d: expected %s in %s", test.version, s, data)
+			}
+		}
+
+		for _, s := range test.excludes {
+			if strings.Contains(string(data), s) {
+				t.Errorf("version %d: unexpected %s in %s", test.version, s, data)
+			}
+		}
+	}
+
+	if result.Version != 0 {
+		t.Errorf("Output changed the result version to %d", result.Version)
+	}
+
+	if _, err := result.Output(LatestOutput + 1); err == nil {
+		t.Error("expected an error for an unknown output version")
+	}
+}
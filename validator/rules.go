@@ -0,0 +1,444 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Severities of rules. Exceptions raised by the schema are errors.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// RuleSet holds policy rules: checks that do not fit JSON Schema, such as
+// "the sum of node pool counts is at most 100". Read one from a rules
+// file with LoadRules or ParseRules and validate with it WithRules.
+//
+// A rules file is a YAML or JSON document:
+//
+//	rules:
+//	  - id: max-nodes
+//	    severity: error
+//	    selector: $.deployment.clusters[*]
+//	    expression: sum(@.nodePools[*].count) <= 100
+//	    message: "{{eval `sum(@.nodePools[*].count)`}} nodes, more than 100"
+//	tests:
+//	  - name: too many nodes
+//	    config: fixtures/big_cluster.yaml
+//	    expect: fail
+//	    rules: [max-nodes]
+//
+// The selector picks the nodes a rule applies to, from the root of the
+// document, and defaults to $, the root itself. Every node it picks must
+// satisfy the expression, see expressions.go, where @ stands for the node
+// and $ for the root. The message is a text/template given the rule, the
+// severity, the pointer, path and value of the node, and an eval function
+// evaluating an expression over it.
+type RuleSet struct {
+	// Name identifies the rules file in errors.
+	Name string
+
+	Rules []*Rule
+
+	// Tests exercise the rules against fixtures; see RunTests.
+	Tests []RuleTest
+}
+
+// Rule is a check of a RuleSet. Breaking it raises an exception of type
+// "rule" in the result of the document.
+type Rule struct {
+	ID         string `json:"id"`
+	Severity   string `json:"severity"`
+	Selector   string `json:"selector"`
+	Expression string `json:"expression"`
+	Message    string `json:"message"`
+
+	selector   selector
+	expression expression
+	message    *template.Template
+}
+
+// RuleTest validates the fixture at Config with the rules only. When Expect
+// is "success" it must break none of them; when it is "fail" it must break
+// some, exactly the Rules listed if any are.
+type RuleTest struct {
+	Name   string   `json:"name"`
+	Config string   `json:"config"`
+	Expect string   `json:"expect"`
+	Rules  []string `json:"rules"`
+}
+
+// LoadRules reads the rules file at path. The configs of its tests are
+// resolved against the directory of the file.
+func LoadRules(path string) (*RuleSet, error) {
+	src, err := fileSourceOf(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules %s: %v", path, err)
+	}
+
+	data, err := src.read(nil, 0, "rules")
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ParseRules(src.Location, data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(src.Location)
+	for i, test := range rules.Tests {
+		if !filepath.IsAbs(test.Config) && !strings.HasPrefix(test.Config, "~") {
+			rules.Tests[i].Config = filepath.Join(dir, test.Config)
+		}
+	}
+
+	return rules, nil
+}
+
+// ParseRules parses the rules file held in data. name identifies the file
+// in errors, which carry the line of the offending rule or test.
+func ParseRules(name string, data []byte) (*RuleSet, error) {
+	document, positions, err := decodeSchema("rules", name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Rules []*Rule    `json:"rules"`
+		Tests []RuleTest `json:"tests"`
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("decoding rules %s: %v", name, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("decoding rules %s: %v", name, err)
+	}
+
+	rules := &RuleSet{Name: name, Rules: file.Rules, Tests: file.Tests}
+
+	at := func(pointer string) string {
+		if span, ok := positions.lookup(pointer, false); ok {
+			return fmt.Sprintf("%s:%d", name, span.Line)
+		}
+		return name
+	}
+
+	ids := map[string]bool{}
+	for i, rule := range rules.Rules {
+		pointer := "/rules/" + strconv.Itoa(i)
+
+		if rule == nil || rule.ID == "" {
+			return nil, fmt.Errorf("%s: rule %d has no id", at(pointer), i)
+		}
+		if ids[rule.ID] {
+			return nil, fmt.Errorf("%s: rule %q is defined twice", at(pointer), rule.ID)
+		}
+		ids[rule.ID] = true
+
+		if field, err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("%s: rule %q: %s: %v", at(appendPointer(pointer, field)), rule.ID, field, err)
+		}
+	}
+
+	for i, test := range rules.Tests {
+		pointer := "/tests/" + strconv.Itoa(i)
+
+		if test.Config == "" {
+			return nil, fmt.Errorf("%s: test %d has no config", at(pointer), i)
+		}
+		if test.Expect != "success" && test.Expect != "fail" {
+			return nil, fmt.Errorf("%s: test %d: expect must be success or fail, got %q", at(pointer), i, test.Expect)
+		}
+		if test.Name == "" {
+			rules.Tests[i].Name = test.Config
+		}
+
+		for _, id := range test.Rules {
+			if !ids[id] {
+				return nil, fmt.Errorf("%s: test %d: unknown rule %q", at(pointer), i, id)
+			}
+		}
+		if len(test.Rules) > 0 && test.Expect != "fail" {
+			return nil, fmt.Errorf("%s: test %d: only failing tests list rules", at(pointer), i)
+		}
+	}
+
+	return rules, nil
+}
+
+// compile parses the selector, expression and message of r, defaulting its
+// severity and selector. It returns the field at fault on failure.
+func (r *Rule) compile() (string, error) {
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityError
+	case SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return "severity", fmt.Errorf("expected %s, %s or %s, got %q", SeverityError, SeverityWarning, SeverityInfo, r.Severity)
+	}
+
+	if r.Selector == "" {
+		r.Selector = "$"
+	}
+
+	sel, err := parseSelector(r.Selector)
+	if err != nil {
+		return "selector", err
+	}
+	if sel.up >= 0 {
+		return "selector", fmt.Errorf("%q must start at the root of the document, $", r.Selector)
+	}
+	r.selector = sel
+
+	if r.Expression == "" {
+		return "expression", errors.New("missing expression")
+	}
+
+	if r.expression, err = parseExpression(r.Expression); err != nil {
+		return "expression", err
+	}
+
+	if r.Message == "" {
+		r.Message = "Does not satisfy " + strings.Replace(r.Expression, "{{", "{{`{{`}}", -1)
+	}
+
+	funcs := template.FuncMap{"eval": func(string) (interface{}, error) { return nil, nil }}
+	if r.message, err = template.New(r.ID).Funcs(funcs).Parse(r.Message); err != nil {
+		return "message", err
+	}
+
+	return "", nil
+}
+
+// check evaluates r over every node its selector picks in document and
+// returns the nodes breaking it, with their messages.
+func (r *Rule) check(document interface{}) []ruleViolation {
+	var violations []ruleViolation
+
+	for _, node := range r.selector.apply(document, "") {
+		env := &exprEnv{document: document, pointer: node.pointer}
+
+		ok, err := evalBool(r.expression, env)
+		switch {
+		case err != nil:
+			violations = append(violations, ruleViolation{node, fmt.Sprintf("Cannot evaluate rule %s: %v", r.ID, err)})
+		case !ok:
+			violations = append(violations, ruleViolation{node, r.render(env, node)})
+		}
+	}
+
+	return violations
+}
+
+// ruleViolation is a node breaking a rule.
+type ruleViolation struct {
+	node    selected
+	message string
+}
+
+// render executes the message template of r for node.
+func (r *Rule) render(env *exprEnv, node selected) string {
+	tpl, err := r.message.Clone()
+	if err != nil {
+		return r.Message
+	}
+
+	tpl.Funcs(template.FuncMap{"eval": func(s string) (interface{}, error) {
+		e, err := parseExpression(s)
+		if err != nil {
+			return nil, err
+		}
+		return e.eval(env)
+	}})
+
+	data := map[string]interface{}{
+		"rule":     r.ID,
+		"severity": r.Severity,
+		"pointer":  node.pointer,
+		"path":     failureField(instanceContext(node.pointer)),
+		"value":    node.value,
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return fmt.Sprintf("Cannot render the message of rule %s: %v", r.ID, err)
+	}
+
+	return buf.String()
+}
+
+// checkRules evaluates the rules of the Validator over document and returns
+// an exception per node breaking one.
+func (v *Validator) checkRules(name string, document interface{}, positions *positionIndex) []ExceptionDetail {
+	var exceptions []ExceptionDetail
+
+	for _, rules := range v.rules {
+		for _, rule := range rules.Rules {
+			for _, violation := range rule.check(document) {
+				context := instanceContext(violation.node.pointer)
+
+				exception := ExceptionDetail{
+					ErrorString: failureField(context) + ": " + violation.message,
+					Path:        context,
+					Type:        "rule",
+					Rule:        rule.ID,
+					Severity:    rule.Severity,
+					Pointer:     violation.node.pointer,
+					Value:       violation.node.value,
+				}
+
+				if span, ok := positions.lookup(violation.node.pointer, false); ok {
+					exception.File = name
+					exception.setSpan(span)
+				}
+
+				exceptions = append(exceptions, exception)
+			}
+		}
+	}
+
+	return exceptions
+}
+
+// loadRules reads the rules files of the Validator and checks the ids of
+// all its rules are unique.
+func (v *Validator) loadRules() error {
+	for _, path := range v.rulesFiles {
+		rules, err := LoadRules(path)
+		if err != nil {
+			return &SchemaError{Schema: path, Err: err}
+		}
+
+		v.rules = append(v.rules, rules)
+	}
+
+	defined := map[string]string{}
+	for _, rules := range v.rules {
+		for _, rule := range rules.Rules {
+			if other, ok := defined[rule.ID]; ok {
+				err := fmt.Errorf("rule %q is defined in both %s and %s", rule.ID, other, rules.Name)
+				return &SchemaError{Schema: rules.Name, Err: err}
+			}
+			defined[rule.ID] = rules.Name
+		}
+	}
+
+	return nil
+}
+
+// RuleTestResult is the outcome of a RuleTest.
+type RuleTestResult struct {
+	Test RuleTest
+
+	// Broken lists the ids of the rules the fixture breaks, once each, in
+	// the order of the rules.
+	Broken []string
+
+	// Err tells why the fixture could not be validated.
+	Err error
+
+	Passed bool
+}
+
+// RunTests validates the fixture of every test with the rules only, and
+// tells whether it breaks the rules it is expected to.
+func (rs *RuleSet) RunTests(ctx context.Context) ([]RuleTestResult, error) {
+	v, err := New(WithSchemaBytes(rs.Name, []byte("{}")), WithRules(rs))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RuleTestResult, 0, len(rs.Tests))
+
+	for _, test := range rs.Tests {
+		outcome := RuleTestResult{Test: test}
+
+		documents, err := v.ValidateFileDocuments(ctx, test.Config)
+		if err != nil {
+			outcome.Err = err
+			results = append(results, outcome)
+			continue
+		}
+
+		broken := map[string]bool{}
+		for _, result := range documents {
+			for _, e := range result.Exceptions {
+				broken[e.Rule] = true
+			}
+		}
+
+		for _, rule := range rs.Rules {
+			if broken[rule.ID] {
+				outcome.Broken = append(outcome.Broken, rule.ID)
+			}
+		}
+
+		switch {
+		case test.Expect == "success":
+			outcome.Passed = len(outcome.Broken) == 0
+		case len(test.Rules) == 0:
+			outcome.Passed = len(outcome.Broken) > 0
+		default:
+			outcome.Passed = sameRules(test.Rules, outcome.Broken)
+		}
+
+		results = append(results, outcome)
+	}
+
+	return results, nil
+}
+
+// sameRules tells whether two lists hold the same rule ids, in any order.
+func sameRules(a []string, b []string) bool {
+	set := map[string]bool{}
+	for _, id := range a {
+		set[id] = true
+	}
+
+	for _, id := range b {
+		if !set[id] {
+			return false
+		}
+	}
+
+	for _, id := range a {
+		found := false
+		for _, other := range b {
+			found = found || other == id
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
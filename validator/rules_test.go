@@ -0,0 +1,244 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRules = `
+rules:
+  - id: max-nodes
+    expression: sum($.pools[*].count) <= 10
+    message: "{{eval ` + "`sum($.pools[*].count)`" + `}} nodes, more than 10"
+
+  - id: odd-etcd
+    severity: warning
+    selector: $.pools[*]
+    expression: "@.name != 'etcd' || @.count % 2 == 1"
+    message: "{{.path}} has {{.value.count}} nodes"
+
+  - id: typed
+    severity: info
+    selector: $.pools[*].count
+    expression: "@ > 0"
+
+  - id: broken
+    selector: $.pools[*]
+    expression: "@.count && true"
+`
+
+func TestRules(t *testing.T) {
+	rules, err := ParseRules("rules.yaml", []byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := New(WithSchemaBytes("schema.json", []byte(`{"properties": {"pools": {"type": "array"}}}`)), WithRules(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		valid    bool
+		errors   []string
+	}{
+		{"pools: []", true, nil},
+		{"pools:\n  - {name: etcd, count: 3}\n", false, []string{
+			`rule broken error /pools/0 pools.0: Cannot evaluate rule broken: expected a boolean, got number 3`,
+		}},
+		{"pools:\n  - {name: etcd, count: 4}\n  - {name: workers, count: 0}\n", false, []string{
+			`rule broken error /pools/0 pools.0: Cannot evaluate rule broken: expected a boolean, got number 4`,
+			`rule odd-etcd warning /pools/0 pools.0: pools.0 has 4 nodes`,
+			`rule broken error /pools/1 pools.1: Cannot evaluate rule broken: expected a boolean, got number 0`,
+			`rule typed info /pools/1/count pools.1.count: Does not satisfy @ > 0`,
+		}},
+		{"pools: 3\n", false, []string{
+			"invalid_type   /pools pools: Invalid type. Expected: array, given: integer",
+		}},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: %v", test.document, err)
+			continue
+		}
+
+		var errors []string
+		for _, e := range result.Exceptions {
+			errors = append(errors, e.Type+" "+e.Rule+" "+e.Severity+" "+e.Pointer+" "+e.ErrorString)
+		}
+
+		if strings.Join(errors, "\n") != strings.Join(test.errors, "\n") || result.IsValid != test.valid {
+			t.Errorf("%s:\nexpected %v %v\ngot      %v %v", test.document, test.valid, test.errors, result.IsValid, errors)
+		}
+	}
+
+	// rules on their own: warnings and notes leave a document valid
+	rules, err = ParseRules("rules.yaml", []byte(testRules[:strings.Index(testRules, "  - id: broken")]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = New(WithSchemaBytes("schema.json", []byte(`{}`)), WithRules(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "config.yaml", []byte("pools:\n  - {name: etcd, count: 8}\n  - {name: workers, count: 4}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.IsValid || len(result.Exceptions) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if e := result.Exceptions[0]; e.ErrorString != "(root): 12 nodes, more than 10" || e.Line != 1 || e.Rule != "max-nodes" || e.Severity != SeverityError {
+		t.Errorf("unexpected exception %+v", e)
+	}
+	if e := result.Exceptions[1]; e.Line != 2 || e.Column != 5 || e.File != "config.yaml" || e.Severity != SeverityWarning {
+		t.Errorf("unexpected exception %+v", e)
+	}
+
+	result, err = v.ValidateBytes(context.Background(), "config.yaml", []byte("pools:\n  - {name: etcd, count: 2}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsValid || len(result.Exceptions) != 1 || len(result.Source) == 0 {
+		t.Errorf("expected a valid result with a warning, got %+v", result)
+	}
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	tests := []struct {
+		rules string
+		err   string
+	}{
+		{"rules: [{id: a, expression: 'true', selctor: $}]", `decoding rules rules.yaml: json: unknown field "selctor"`},
+		{"rules:\n  - expression: 'true'\n", "rules.yaml:2: rule 0 has no id"},
+		{"rules:\n  - {id: a, expression: 'true'}\n  - {id: a, expression: 'true'}\n", `rules.yaml:3: rule "a" is defined twice`},
+		{"rules:\n  - id: a\n    severity: fatal\n    expression: 'true'\n", `rules.yaml:3: rule "a": severity: expected error, warning or info, got "fatal"`},
+		{"rules:\n  - id: a\n    selector: 1.name\n    expression: 'true'\n", `rules.yaml:3: rule "a": selector: "1.name" must start at the root of the document, $`},
+		{"rules:\n  - id: a\n    selector: $[x]\n    expression: 'true'\n", `rules.yaml:3: rule "a": selector: invalid index "x" in "$[x]"`},
+		{"rules:\n  - id: a\n", `rules.yaml:2: rule "a": expression: missing expression`},
+		{"rules:\n  - id: a\n    expression: '@ =='\n", `rules.yaml:3: rule "a": expression: unexpected end of expression at offset 4`},
+		{"rules:\n  - id: a\n    expression: 'true'\n    message: '{{.value'\n", `rules.yaml:4: rule "a": message: template: a:1: unclosed action`},
+		{"rules: [{id: a, expression: 'true'}]\ntests:\n  - expect: fail\n", "rules.yaml:3: test 0 has no config"},
+		{"rules: [{id: a, expression: 'true'}]\ntests:\n  - {config: a.yaml, expect: pass}\n", `rules.yaml:3: test 0: expect must be success or fail, got "pass"`},
+		{"rules: [{id: a, expression: 'true'}]\ntests:\n  - {config: a.yaml, expect: fail, rules: [b]}\n", `rules.yaml:3: test 0: unknown rule "b"`},
+		{"rules: [{id: a, expression: 'true'}]\ntests:\n  - {config: a.yaml, expect: success, rules: [a]}\n", "rules.yaml:3: test 0: only failing tests list rules"},
+		{"rules: a\n---\nrules: b\n", "decoding rules rules.yaml: found 2 YAML documents; the rules must be a single document"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseRules("rules.yaml", []byte(test.rules))
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%s: expected error %q, got %v", test.rules, test.err, err)
+		}
+	}
+
+	// the name is reported as given
+	_, err := ParseRules("schemas/rules.yaml", []byte("rules: [a\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "decoding rules schemas/rules.yaml: ") {
+		t.Errorf("expected a decoding error naming schemas/rules.yaml, got %v", err)
+	}
+
+	// ids are unique across rules files
+	a, _ := ParseRules("a.yaml", []byte("rules: [{id: a, expression: 'true'}]"))
+	b, _ := ParseRules("b.yaml", []byte("rules: [{id: a, expression: 'true'}]"))
+	_, err = New(WithSchemaBytes("schema.json", []byte(`{}`)), WithRules(a), WithRules(b))
+	if _, ok := err.(*SchemaError); !ok || err.Error() != `rule "a" is defined in both a.yaml and b.yaml` {
+		t.Errorf("expected a duplicate rule error, got %v", err)
+	}
+
+	_, err = New(WithSchemaBytes("schema.json", []byte(`{}`)), WithRulesFile("missing_rules.yaml"))
+	if _, ok := err.(*SchemaError); !ok {
+		t.Errorf("expected a missing rules file to be a *SchemaError, got %v", err)
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"rules.yaml": testRules[:strings.Index(testRules, "  - id: broken")] + `
+tests:
+  - {name: small, config: fixtures/small.yaml, expect: success}
+  - {name: big, config: fixtures/big.yaml, expect: fail}
+  - {name: big and even, config: fixtures/big.yaml, expect: fail, rules: [max-nodes, odd-etcd]}
+  - {name: wrong, config: fixtures/big.yaml, expect: success}
+  - {name: wrong rules, config: fixtures/big.yaml, expect: fail, rules: [max-nodes]}
+  - {config: fixtures/missing.yaml, expect: success}
+`,
+		"fixtures/small.yaml": "pools: [{name: etcd, count: 3}]\n",
+		"fixtures/big.yaml":   "pools: [{name: etcd, count: 4}, {name: workers, count: 10}]\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, err := LoadRules(filepath.Join(dir, "rules.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := rules.RunTests(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var outcomes []string
+	for _, result := range results {
+		outcome := "fail"
+		switch {
+		case result.Err != nil:
+			outcome = "error"
+		case result.Passed:
+			outcome = "pass"
+		}
+		outcomes = append(outcomes, result.Test.Name+" "+outcome+" "+strings.Join(result.Broken, ","))
+	}
+
+	expected := []string{
+		"small pass ",
+		"big pass max-nodes,odd-etcd",
+		"big and even pass max-nodes,odd-etcd",
+		"wrong fail max-nodes,odd-etcd",
+		"wrong rules fail max-nodes,odd-etcd",
+		"fixtures/missing.yaml error ",
+	}
+
+	if strings.Join(outcomes, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(outcomes, "\n"))
+	}
+}
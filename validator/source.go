@@ -0,0 +1,237 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// StdinName is the location reported for a schema or config read from
+// standard input: "-", as it is given.
+const StdinName = "-"
+
+type sourceKind int
+
+const (
+	fileSource sourceKind = iota
+	urlSource
+	stdinSource
+)
+
+// Source is where a schema or a config is read from. It is parsed from a
+// location as given on the command line: a file path, relative to the
+// working directory or starting with "~", a file:// URI, an http(s):// URL,
+// or "-" for standard input.
+type Source struct {
+	// Location is the resolved location reported in results: the absolute,
+	// cleaned path of a file, with "~" expanded and any file:// scheme
+	// removed, the URL, or StdinName.
+	Location string
+
+	kind sourceKind
+
+	// path is the absolute path of a file.
+	path string
+}
+
+// ParseSource resolves location into a Source. Relative paths are resolved
+// against the working directory at the time of the call.
+func ParseSource(location string) (Source, error) {
+	switch {
+	case location == "":
+		return Source{}, errors.New("empty location given")
+
+	case location == "-":
+		return Source{Location: StdinName, kind: stdinSource}, nil
+
+	case isURL(location):
+		u, err := url.Parse(location)
+		if err != nil {
+			return Source{}, err
+		}
+
+		if u.Host == "" {
+			return Source{}, fmt.Errorf("invalid URL %q; expected an absolute http(s) URL", location)
+		}
+
+		return Source{Location: u.String(), kind: urlSource}, nil
+
+	case strings.HasPrefix(strings.ToLower(location), "file:"):
+		path, err := fileURIPath(location)
+		if err != nil {
+			return Source{}, err
+		}
+
+		return fileSourceOf(path)
+	}
+
+	return fileSourceOf(location)
+}
+
+// resolvedLocation returns the Location of the source at location, or
+// location itself when it cannot be parsed.
+func resolvedLocation(location string) string {
+	if src, err := ParseSource(location); err == nil {
+		return src.Location
+	}
+
+	return location
+}
+
+// fileSourceOf returns the Source of the file at path, expanding a leading
+// "~" to the home directory.
+func fileSourceOf(path string) (Source, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := homeDir()
+		if err != nil {
+			return Source{}, fmt.Errorf("expanding %s: %v", path, err)
+		}
+
+		path = filepath.Join(home, path[1:])
+	} else if strings.HasPrefix(path, "~") {
+		return Source{}, fmt.Errorf("expanding %s: only the home directory of the current user, ~, is supported", path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Source{}, err
+	}
+
+	return Source{Location: abs, kind: fileSource, path: abs}, nil
+}
+
+// fileURIPath returns the path of a file:// URI. Besides the usual
+// file:///absolute/path, it accepts relative paths written as
+// file:relative/path and file://./relative/path or file://../relative/path,
+// and file://localhost/absolute/path. In file://relative/path, relative is
+// the host, so it is rejected like any other host.
+func fileURIPath(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+
+	var path string
+
+	switch {
+	case u.Opaque != "":
+		path = u.Opaque
+	case u.Host == "" || u.Host == "localhost":
+		path = u.Path
+	case u.Host == "." || u.Host == "..":
+		path = u.Host + u.Path
+	default:
+		return "", fmt.Errorf("unsupported file URI %q; the file must be on this host, and relative paths are written file:relative/path", location)
+	}
+
+	if path == "" {
+		return "", fmt.Errorf("invalid file URI %q; no path given", location)
+	}
+
+	// file:///C:/dir on Windows
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+
+	return filepath.FromSlash(path), nil
+}
+
+// homeDir returns the home directory of the current user.
+func homeDir() (string, error) {
+	for _, name := range []string{"HOME", "USERPROFILE"} {
+		if home := os.Getenv(name); home != "" {
+			return home, nil
+		}
+	}
+
+	return "", errors.New("the home directory is unknown; $HOME is not set")
+}
+
+// IsStdin reports whether the source is standard input.
+func (s Source) IsStdin() bool {
+	return s.kind == stdinSource
+}
+
+// IsURL reports whether the source is an http(s) URL.
+func (s Source) IsURL() bool {
+	return s.kind == urlSource
+}
+
+// URI returns the URL of the source, or the file:// URI of its absolute
+// path. It is empty for standard input.
+func (s Source) URI() string {
+	switch s.kind {
+	case urlSource:
+		return s.Location
+	case fileSource:
+		return fileURI(s.path)
+	}
+
+	return ""
+}
+
+// fileURI returns the file:// URI of the absolute path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// C:/dir on Windows
+		path = "/" + path
+	}
+
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+func (s Source) String() string {
+	return s.Location
+}
+
+// read reads a local source, a file or stdin, failing past limit bytes.
+// what names the source in errors, e.g. "config".
+func (s Source) read(stdin io.Reader, limit int64, what string) ([]byte, error) {
+	var r io.Reader
+
+	switch s.kind {
+	case stdinSource:
+		r = stdin
+	case fileSource:
+		if _, err := fileExists(s.path); err != nil {
+			return nil, fmt.Errorf("reading %s %s: %v", what, s.Location, err)
+		}
+
+		f, err := os.Open(s.path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s %s: %v", what, s.Location, err)
+		}
+		defer f.Close()
+
+		r = f
+	default:
+		return nil, fmt.Errorf("reading %s %s: a %s must be a file or standard input", what, s.Location, what)
+	}
+
+	data, err := readLimited(r, limit)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s %s: %v", what, s.Location, err)
+	}
+
+	return data, nil
+}
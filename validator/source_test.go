@@ -0,0 +1,143 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	os.Setenv("HOME", "/home/me")
+
+	tests := []struct {
+		location string
+		expect   string
+		uri      string
+	}{
+		{"schema.json", filepath.Join(cwd, "schema.json"), "file://" + filepath.ToSlash(filepath.Join(cwd, "schema.json"))},
+		{"./schemas/../schema.yaml", filepath.Join(cwd, "schema.yaml"), "file://" + filepath.ToSlash(filepath.Join(cwd, "schema.yaml"))},
+		{"/etc/schema.json", "/etc/schema.json", "file:///etc/schema.json"},
+		{"~", "/home/me", "file:///home/me"},
+		{"~/schemas/a b.json", "/home/me/schemas/a b.json", "file:///home/me/schemas/a%20b.json"},
+		{"file:///etc/schema.json", "/etc/schema.json", "file:///etc/schema.json"},
+		{"file://localhost/etc/schema.json", "/etc/schema.json", "file:///etc/schema.json"},
+		{"file://../schema.json", filepath.Join(filepath.Dir(cwd), "schema.json"), "file://" + filepath.ToSlash(filepath.Join(filepath.Dir(cwd), "schema.json"))},
+		{"file:schema.json", filepath.Join(cwd, "schema.json"), "file://" + filepath.ToSlash(filepath.Join(cwd, "schema.json"))},
+		{"https://example.com/schema.json", "https://example.com/schema.json", "https://example.com/schema.json"},
+		{"-", "-", ""},
+	}
+
+	for _, test := range tests {
+		src, err := ParseSource(test.location)
+		if err != nil {
+			t.Errorf("%s: %v", test.location, err)
+			continue
+		}
+
+		if src.Location != test.expect || src.URI() != test.uri {
+			t.Errorf("%s: expected %s at %s, got %s at %s", test.location, test.expect, test.uri, src.Location, src.URI())
+		}
+	}
+
+	// file://schemas/schema.json names the host schemas, not a relative path
+	for _, location := range []string{"", "~other/schema.json", "file://example.com/schema.json", "file://schemas/schema.json", "https:///schema.json"} {
+		if _, err := ParseSource(location); err == nil {
+			t.Errorf("%q: expected an error", location)
+		}
+	}
+}
+
+func TestSources(t *testing.T) {
+	dir := writeSchemas(t, map[string]string{
+		"schemas/root.yaml":  "properties:\n  port:\n    $ref: port.json\n",
+		"schemas/port.json":  `{ "type": "integer" }`,
+		"configs/valid.yaml": "port: 80\n",
+	})
+	defer os.RemoveAll(dir)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	if err := os.Chdir(filepath.Join(dir, "configs")); err != nil {
+		t.Fatal(err)
+	}
+
+	// relative $refs of a relative schema path resolve
+	v, err := New(WithSchemaFile("../schemas/root.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateFile(context.Background(), "file:valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.IsValid || result.Config != filepath.Join(dir, "configs", "valid.yaml") || result.Schema != filepath.Join(dir, "schemas", "root.yaml") {
+		t.Errorf("expected valid.yaml to validate against ../schemas/root.yaml, reported by their absolute paths, got %+v", result)
+	}
+
+	// a config piped in
+	v, err = New(WithSchema("file://../schemas/root.yaml"), WithStdin(strings.NewReader("port: eighty\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = v.ValidateFile(context.Background(), "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.IsValid || result.Config != StdinName {
+		t.Errorf("expected the config read from stdin to be invalid, got %+v", result)
+	}
+
+	// a schema piped in resolves its $refs against the working directory
+	if err := os.Chdir(filepath.Join(dir, "schemas")); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = New(WithSchema("-"), WithStdin(strings.NewReader(`{ "properties": { "port": { "$ref": "port.json" } } }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = v.ValidateFile(context.Background(), "../configs/valid.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.IsValid || result.Schema != StdinName {
+		t.Errorf("expected valid.yaml to validate against the schema read from stdin, got %+v", result)
+	}
+
+	if _, err := v.ValidateFile(context.Background(), "https://example.com/config.yaml"); err == nil {
+		t.Error("expected an error for a config URL")
+	}
+}
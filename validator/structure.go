@@ -0,0 +1,328 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Standard output structures of JSON Schema (draft 2019-09, section 10.4),
+// see Structured.
+const (
+	// OutputFlag only tells whether the document is valid.
+	OutputFlag = "flag"
+
+	// OutputBasic lists every error, the errors of anyOf and oneOf
+	// branches included, in a flat list.
+	OutputBasic = "basic"
+
+	// OutputDetailed nests the errors under the schemas they were raised
+	// in, leaving out the schemas holding a single other one.
+	OutputDetailed = "detailed"
+
+	// OutputVerbose nests the errors under every schema evaluated on the
+	// way from the root schema to them.
+	OutputVerbose = "verbose"
+)
+
+// OutputStructures lists the output structures supported by Structured.
+var OutputStructures = []string{OutputFlag, OutputBasic, OutputDetailed, OutputVerbose}
+
+// OutputUnit is a node of the standard output structures: a schema
+// evaluated on an instance location and the errors it raised, directly in
+// Error or through its subschemas in Errors.
+type OutputUnit struct {
+	Valid                   bool          `json:"valid"`
+	KeywordLocation         string        `json:"keywordLocation"`
+	AbsoluteKeywordLocation string        `json:"absoluteKeywordLocation,omitempty"`
+	InstanceLocation        string        `json:"instanceLocation"`
+	Error                   string        `json:"error,omitempty"`
+	Errors                  []*OutputUnit `json:"errors,omitempty"`
+}
+
+// outputRoot is the root of the flag and basic structures.
+type outputRoot struct {
+	Valid  bool          `json:"valid"`
+	Errors []*OutputUnit `json:"errors,omitempty"`
+}
+
+// outputLocation is a schema evaluated on the way to an error: its path of
+// keywords, its absolute URI and the instance it applied to.
+type outputLocation struct {
+	keyword  string
+	absolute string
+	instance string
+}
+
+// Structured returns r laid out as one of the standard output structures of
+// JSON Schema, ready to be marshalled to JSON. gojsonschema only reports
+// failures, so the structures hold the schemas leading to errors and leave
+// out the ones that passed.
+func (r *ValidatorResult) Structured(structure string) (interface{}, error) {
+	switch structure {
+	case OutputFlag:
+		return &outputRoot{Valid: r.IsValid}, nil
+
+	case OutputBasic:
+		root := &outputRoot{Valid: r.IsValid}
+
+		var add func(e ExceptionDetail)
+		add = func(e ExceptionDetail) {
+			root.Errors = append(root.Errors, &OutputUnit{
+				KeywordLocation:         e.KeywordLocation,
+				AbsoluteKeywordLocation: e.AbsoluteKeywordLocation,
+				InstanceLocation:        e.Pointer,
+				Error:                   e.ErrorString,
+			})
+
+			for _, cause := range e.Causes {
+				add(cause)
+			}
+		}
+
+		for _, e := range r.structuredExceptions() {
+			add(e)
+		}
+
+		return root, nil
+
+	case OutputDetailed, OutputVerbose:
+		tree := &outputTree{root: &OutputUnit{Valid: r.IsValid}, units: map[string]*OutputUnit{}}
+		tree.units["\x00"] = tree.root
+
+		for _, e := range r.structuredExceptions() {
+			tree.add(e)
+		}
+
+		if structure == OutputDetailed {
+			condense(tree.root)
+		}
+
+		return tree.root, nil
+	}
+
+	return nil, fmt.Errorf("unsupported output structure %q; supported structures are %s",
+		structure, strings.Join(OutputStructures, ", "))
+}
+
+// structuredExceptions returns the exceptions of r without the errors
+// gojsonschema merged from anyOf and oneOf branches, see withoutMerged.
+func (r *ValidatorResult) structuredExceptions() []ExceptionDetail {
+	return withoutMerged(r.Exceptions)
+}
+
+// withoutMerged returns exceptions without those repeating a cause of
+// another one: gojsonschema merges the errors of the closest branch of an
+// anyOf or oneOf with the others, and Causes already holds them.
+func withoutMerged(exceptions []ExceptionDetail) []ExceptionDetail {
+	causes := map[string]bool{}
+
+	var collect func(e ExceptionDetail)
+	collect = func(e ExceptionDetail) {
+		for _, cause := range e.Causes {
+			causes[exceptionKey(cause)] = true
+			collect(cause)
+		}
+	}
+
+	for _, e := range exceptions {
+		collect(e)
+	}
+
+	var kept []ExceptionDetail
+	for _, e := range exceptions {
+		if !causes[exceptionKey(e)] {
+			kept = append(kept, e)
+		}
+	}
+
+	return kept
+}
+
+// exceptionKey identifies the error behind an exception.
+func exceptionKey(e ExceptionDetail) string {
+	return e.Type + "\x00" + e.Pointer + "\x00" + e.ErrorString
+}
+
+// outputTree builds the detailed and verbose structures, one unit per
+// keyword location and instance location.
+type outputTree struct {
+	root  *OutputUnit
+	units map[string]*OutputUnit
+}
+
+// add adds the units leading to e, e itself and its causes.
+func (t *outputTree) add(e ExceptionDetail) {
+	parent := t.root
+
+	if len(e.units) > 0 {
+		// the first unit is the root schema
+		for _, l := range e.units[1:] {
+			parent = t.unit(parent, l)
+		}
+	}
+
+	leaf := t.unit(parent, outputLocation{keyword: e.KeywordLocation, absolute: e.AbsoluteKeywordLocation, instance: e.Pointer})
+	leaf.Error = e.ErrorString
+
+	for _, cause := range e.Causes {
+		t.add(cause)
+	}
+}
+
+// unit returns the unit at l, adding it under parent when it is new.
+func (t *outputTree) unit(parent *OutputUnit, l outputLocation) *OutputUnit {
+	key := l.keyword + "\x00" + l.instance
+	if u, ok := t.units[key]; ok {
+		return u
+	}
+
+	u := &OutputUnit{
+		KeywordLocation:         l.keyword,
+		AbsoluteKeywordLocation: l.absolute,
+		InstanceLocation:        l.instance,
+	}
+
+	t.units[key] = u
+	parent.Errors = append(parent.Errors, u)
+
+	return u
+}
+
+// condense replaces the units below u that hold no error of their own and a
+// single subschema with that subschema.
+func condense(u *OutputUnit) {
+	for i, child := range u.Errors {
+		for child.Error == "" && len(child.Errors) == 1 {
+			child = child.Errors[0]
+		}
+
+		u.Errors[i] = child
+		condense(child)
+	}
+}
+
+// units returns the schemas evaluated from the root schema down to the
+// keyword at keywords, a path of keywords as in
+// ExceptionDetail.KeywordLocation, which failed on the instance at
+// instance. The keyword itself is left out.
+func (b *schemaBundle) units(keywords string, instance string) []outputLocation {
+	tokens := splitPointer(keywords)
+	path := splitPointer(instance)
+
+	at := location{document: b.root}
+	current := outputLocation{absolute: at.String()}
+	units := []outputLocation{current}
+
+	descend := func(tokens ...string) {
+		for _, token := range tokens {
+			current.keyword = appendPointer(current.keyword, token)
+			at.pointer = appendPointer(at.pointer, token)
+		}
+		current.absolute = at.String()
+	}
+
+	// next moves to the instance member or item the subschema applies to
+	next := func() bool {
+		if len(path) == 0 {
+			return false
+		}
+
+		current.instance = appendPointer(current.instance, path[0])
+		path = path[1:]
+
+		return true
+	}
+
+	last := len(tokens) - 1
+
+	for i := 0; i < last; {
+		token := tokens[i]
+
+		switch token {
+		case "properties", "patternProperties":
+			if i+1 >= last {
+				return units
+			}
+
+			descend(token, tokens[i+1])
+			i += 2
+
+			if !next() {
+				return units
+			}
+
+		case "additionalProperties", "additionalItems":
+			descend(token)
+			i++
+
+			if !next() {
+				return units
+			}
+
+		case "items":
+			if _, err := strconv.Atoi(tokens[i+1]); err == nil && i+1 < last {
+				descend(token, tokens[i+1])
+				i += 2
+			} else {
+				descend(token)
+				i++
+			}
+
+			if !next() {
+				return units
+			}
+
+		case "not":
+			descend(token)
+			i++
+
+		case "allOf", "anyOf", "oneOf":
+			descend(token)
+			units = append(units, current)
+
+			if i+1 >= last {
+				return units
+			}
+
+			descend(tokens[i+1])
+			i += 2
+
+		case "$ref":
+			node, _ := resolvePointer(b.documents[at.document], at.pointer)
+			object, _ := node.(map[string]interface{})
+			ref, _ := object["$ref"].(string)
+
+			target, ok := b.internal[ref]
+			if !ok {
+				return units
+			}
+
+			at = target
+			current.keyword = appendPointer(current.keyword, token)
+			current.absolute = at.String()
+			i++
+
+		default:
+			return units
+		}
+
+		units = append(units, current)
+	}
+
+	return units
+}
@@ -0,0 +1,151 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStructured(t *testing.T) {
+	v, err := New(WithSchemaBytes("branches.json", branchSchema), WithCauses())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := v.ValidateBytes(context.Background(), "branches.yaml", []byte("services:\n- port: Web\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(structure string) string {
+		out, err := result.Structured(structure)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(data)
+	}
+
+	if flag := encode(OutputFlag); flag != `{"valid":false}` {
+		t.Errorf("unexpected flag output %s", flag)
+	}
+
+	var basic struct {
+		Valid  bool
+		Errors []OutputUnit
+	}
+	if err := json.Unmarshal([]byte(encode(OutputBasic)), &basic); err != nil {
+		t.Fatal(err)
+	}
+
+	// the anyOf error and the error of each branch, once
+	port := "/properties/services/items/properties/port"
+	var keywords []string
+	for _, unit := range basic.Errors {
+		if unit.InstanceLocation != "/services/0/port" || unit.Error == "" {
+			t.Errorf("unexpected basic unit %+v", unit)
+		}
+		keywords = append(keywords, unit.KeywordLocation)
+	}
+
+	if got, want := strings.Join(keywords, " "), port+"/anyOf "+port+"/anyOf/0/$ref/type "+port+"/anyOf/1/$ref/pattern"; got != want {
+		t.Errorf("expected basic units %s, got %s", want, got)
+	}
+
+	// verbose keeps every schema on the way, detailed only those holding
+	// errors or several subschemas
+	paths := func(structure string) []string {
+		out, err := result.Structured(structure)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var list []string
+		var walk func(u *OutputUnit, depth int)
+		walk = func(u *OutputUnit, depth int) {
+			list = append(list, strings.Repeat(" ", depth)+u.KeywordLocation)
+			for _, child := range u.Errors {
+				walk(child, depth+1)
+			}
+		}
+		walk(out.(*OutputUnit), 0)
+
+		return list
+	}
+
+	verbose := []string{
+		"",
+		" /properties/services",
+		"  /properties/services/items",
+		"   " + port,
+		"    " + port + "/anyOf",
+		"     " + port + "/anyOf/0",
+		"      " + port + "/anyOf/0/$ref",
+		"       " + port + "/anyOf/0/$ref/type",
+		"     " + port + "/anyOf/1",
+		"      " + port + "/anyOf/1/$ref",
+		"       " + port + "/anyOf/1/$ref/pattern",
+	}
+	if got := paths(OutputVerbose); strings.Join(got, "\n") != strings.Join(verbose, "\n") {
+		t.Errorf("expected verbose units:\n%s\ngot:\n%s", strings.Join(verbose, "\n"), strings.Join(got, "\n"))
+	}
+
+	detailed := []string{
+		"",
+		" " + port + "/anyOf",
+		"  " + port + "/anyOf/0/$ref/type",
+		"  " + port + "/anyOf/1/$ref/pattern",
+	}
+	if got := paths(OutputDetailed); strings.Join(got, "\n") != strings.Join(detailed, "\n") {
+		t.Errorf("expected detailed units:\n%s\ngot:\n%s", strings.Join(detailed, "\n"), strings.Join(got, "\n"))
+	}
+
+	out, _ := result.Structured(OutputVerbose)
+	ref := out.(*OutputUnit).Errors[0].Errors[0].Errors[0].Errors[0].Errors[0].Errors[0]
+	if !strings.HasSuffix(ref.AbsoluteKeywordLocation, "branches.json#/definitions/port") {
+		t.Errorf("expected the $ref unit to locate its target, got %q", ref.AbsoluteKeywordLocation)
+	}
+
+	if _, err := result.Structured("compact"); err == nil {
+		t.Error("expected an error for an unknown output structure")
+	}
+}
+
+func TestStructuredValid(t *testing.T) {
+	result := &ValidatorResult{IsValid: true}
+
+	for structure, want := range map[string]string{
+		OutputFlag:     `{"valid":true}`,
+		OutputBasic:    `{"valid":true}`,
+		OutputDetailed: `{"valid":true,"keywordLocation":"","instanceLocation":""}`,
+	} {
+		out, err := result.Structured(structure)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if data, _ := json.Marshal(out); string(data) != want {
+			t.Errorf("%s: expected %s, got %s", structure, want, data)
+		}
+	}
+}
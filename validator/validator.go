@@ -0,0 +1,486 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonreference"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Validator validates documents against one compiled JSON schema. Build it
+// with New and reuse it; a Validator is safe for concurrent use.
+type Validator struct {
+	schemaName      string
+	schemaURI       string
+	schemaData      []byte
+	schemaStdin     bool
+	stdin           io.Reader
+	schema          *gojsonschema.Schema
+	bundle          *schemaBundle
+	formats         *FormatRegistry
+	locale          Locale
+	maxDocumentSize int64
+	maxErrors       int
+	singleDocument  bool
+	cache           *SchemaCache
+	subschemas      *subschemaCache
+	causes          bool
+	maxSchemaSize   int64
+	httpClient      *http.Client
+	httpTimeout     time.Duration
+	httpHeaders     http.Header
+	rules           []*RuleSet
+	rulesFiles      []string
+}
+
+// New builds a Validator from opts and compiles its schema. A schema source,
+// such as WithSchemaFile, WithSchemaURL or WithSchemaBytes, is required.
+func New(opts ...Option) (*Validator, error) {
+	v := &Validator{
+		formats:       DefaultFormats(),
+		maxSchemaSize: DefaultMaxSchemaSize,
+		httpTimeout:   DefaultHTTPTimeout,
+		httpHeaders:   http.Header{},
+		subschemas:    newSubschemaCache(maxSubschemas),
+		stdin:         os.Stdin,
+	}
+
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, &UsageError{Err: err}
+		}
+	}
+
+	rootURI := v.schemaURI
+
+	switch {
+	case v.schemaURI != "":
+	case v.schemaStdin:
+		data, err := Source{Location: StdinName, kind: stdinSource}.read(v.stdin, v.maxSchemaSize, "schema")
+		if err != nil {
+			return nil, &SchemaError{Schema: v.schemaName, Err: err}
+		}
+
+		v.schemaData = data
+		rootURI = bytesSchemaURI("-")
+	case v.schemaData != nil:
+		rootURI = bytesSchemaURI(v.schemaName)
+	default:
+		return nil, &UsageError{Err: errors.New("no schema given; use WithSchema, WithSchemaFile, WithSchemaURL or WithSchemaBytes")}
+	}
+
+	bundle, err := loadSchemaBundle(v.newFetcher(), rootURI, v.schemaData)
+	if err != nil {
+		return nil, &SchemaError{Schema: v.schemaName, Err: err}
+	}
+
+	bundle.stripFormats(v.formats)
+	if err := bundle.checkExtensions(v.schemaName); err != nil {
+		return nil, &SchemaError{Schema: v.schemaName, Err: err}
+	}
+	if err := bundle.checkDataRefs(v.schemaName); err != nil {
+		return nil, &SchemaError{Schema: v.schemaName, Err: err}
+	}
+
+	schema, err := v.cache.compile(bundle, v.formats)
+	if err != nil {
+		return nil, &SchemaError{Schema: v.schemaName, Err: bundle.compileError(v.schemaName, err)}
+	}
+	v.schema = schema
+	v.bundle = bundle
+
+	if err := v.loadRules(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// newFetcher builds the loader factory that fetches the schema and every
+// document it references.
+func (v *Validator) newFetcher() *schemaFetcher {
+	client := v.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: v.httpTimeout}
+	}
+
+	fetcher := &schemaFetcher{
+		client:  client,
+		maxSize: v.maxSchemaSize,
+		headers: v.httpHeaders,
+	}
+
+	if u, err := url.Parse(v.schemaURI); err == nil {
+		fetcher.headerHost = u.Host
+	}
+
+	return fetcher
+}
+
+// bytesSchemaURI returns the URI standing for a schema given as bytes: the
+// file its name would refer to, so relative $refs resolve against it.
+func bytesSchemaURI(name string) string {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		path = name
+	}
+
+	return fileURI(path)
+}
+
+// SchemaName returns the name of the schema the Validator validates against.
+func (v *Validator) SchemaName() string {
+	return v.schemaName
+}
+
+// ValidateFile validates the YAML or JSON document stored at path, which may
+// be any location ParseSource accepts but a URL, e.g. "-" for standard
+// input. Results name the config by its resolved Source.Location.
+//
+// A document that cannot be read or parsed is reported as a *ConfigError. A
+// document that does not validate is not an error; see ValidatorResult.Err.
+// Files holding several YAML documents are reported as a *ConfigError too;
+// use ValidateFileDocuments for those.
+func (v *Validator) ValidateFile(ctx context.Context, path string) (*ValidatorResult, error) {
+	name, data, err := v.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ValidateBytes(ctx, name, data)
+}
+
+// ValidateFileDocuments validates each document of the YAML stream, or the
+// JSON document, stored at path. path is read as in ValidateFile. See
+// ValidateDocuments.
+func (v *Validator) ValidateFileDocuments(ctx context.Context, path string) ([]*ValidatorResult, error) {
+	name, data, err := v.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ValidateDocuments(ctx, name, data)
+}
+
+// readFile reads the config stored at path, returning its resolved location
+// along with it.
+func (v *Validator) readFile(path string) (string, []byte, error) {
+	src, err := ParseSource(path)
+	if err != nil {
+		return "", nil, &ConfigError{Config: path, Err: fmt.Errorf("reading config %s: %v", path, err)}
+	}
+
+	data, err := src.read(v.stdin, v.maxDocumentSize, "config")
+	if err != nil {
+		return "", nil, &ConfigError{Config: src.Location, Err: err}
+	}
+
+	return src.Location, data, nil
+}
+
+// Validate reads a YAML or JSON document from r and validates it. name
+// identifies the document in the result.
+func (v *Validator) Validate(ctx context.Context, name string, r io.Reader) (*ValidatorResult, error) {
+	data, err := readLimited(r, v.maxDocumentSize)
+	if err != nil {
+		return nil, &ConfigError{Config: name, Err: fmt.Errorf("reading config %s: %v", name, err)}
+	}
+
+	return v.ValidateBytes(ctx, name, data)
+}
+
+// ValidateBytes validates the YAML or JSON document held in data. name
+// identifies the document in the result. data must hold a single document;
+// see ValidateDocuments for YAML streams.
+func (v *Validator) ValidateBytes(ctx context.Context, name string, data []byte) (*ValidatorResult, error) {
+	documents, err := v.split(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documents) > 1 {
+		err := fmt.Errorf("config %s holds %d YAML documents; validate each of them with ValidateDocuments", name, len(documents))
+		return nil, &ConfigError{Config: name, Err: err}
+	}
+
+	return v.validateDocument(ctx, name, data, documents, 0)
+}
+
+// ValidateDocuments validates each document of the YAML stream, or the JSON
+// document, held in data independently, and returns their results in order.
+// Each result records the index of its document and the line it starts on.
+// name identifies the stream in the results.
+//
+// A document that cannot be parsed fails the whole stream with a
+// *ConfigError, as does a stream of several documents when the Validator
+// was built WithSingleDocument.
+func (v *Validator) ValidateDocuments(ctx context.Context, name string, data []byte) ([]*ValidatorResult, error) {
+	documents, err := v.split(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.singleDocument && len(documents) > 1 {
+		err := fmt.Errorf("config %s holds %d YAML documents; exactly one is allowed", name, len(documents))
+		return nil, &ConfigError{Config: name, Err: err}
+	}
+
+	results := make([]*ValidatorResult, 0, len(documents))
+
+	for i := range documents {
+		result, err := v.validateDocument(ctx, name, data, documents, i)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// split checks the size of data and splits it into its documents.
+func (v *Validator) split(name string, data []byte) ([]yamlDocument, error) {
+	if v.maxDocumentSize > 0 && int64(len(data)) > v.maxDocumentSize {
+		err := fmt.Errorf("reading config %s: document exceeds the maximum size of %d bytes", name, v.maxDocumentSize)
+		return nil, &ConfigError{Config: name, Err: err}
+	}
+
+	return splitDocuments(data), nil
+}
+
+// validateDocument validates documents[index], a document held in data.
+func (v *Validator) validateDocument(ctx context.Context, name string, data []byte, documents []yamlDocument, index int) (*ValidatorResult, error) {
+	document := documents[index]
+
+	parseError := func(err error) error {
+		if len(documents) > 1 {
+			err = fmt.Errorf("parsing document %d of config %s, starting on line %d: %v", index, name, document.line, err)
+		} else {
+			err = fmt.Errorf("parsing config %s: %v", name, err)
+		}
+
+		return &ConfigError{Config: name, Err: err}
+	}
+
+	value, positions, err := fileContentsNormalizer(data, document)
+	if err != nil {
+		return nil, parseError(err)
+	}
+
+	result, err := v.validate(ctx, name, value, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Document = index
+	result.Documents = len(documents)
+	result.StartLine = document.line
+
+	if len(result.Exceptions) > 0 {
+		result.Source = data
+	}
+
+	return result, nil
+}
+
+// ValidateValue validates an already decoded document, such as the
+// map[string]interface{} produced by encoding/json. name identifies the
+// document in the result.
+func (v *Validator) ValidateValue(ctx context.Context, name string, value interface{}) (*ValidatorResult, error) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, &ConfigError{Config: name, Err: fmt.Errorf("encoding config %s: %v", name, err)}
+	}
+
+	document, err := decodeJSON(jsonData)
+	if err != nil {
+		return nil, &ConfigError{Config: name, Err: fmt.Errorf("parsing config %s: %v", name, err)}
+	}
+
+	result, err := v.validate(ctx, name, document, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Documents = 1
+
+	return result, nil
+}
+
+// validate runs the compiled schema over the decoded document. gojsonschema
+// cannot be interrupted, so ctx is only honoured before and after the run.
+// When positions is set, exceptions carry where the offending node was
+// written.
+func (v *Validator) validate(ctx context.Context, name string, document interface{}, positions *positionIndex) (*ValidatorResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	validated, err := v.schema.Validate(&documentLoader{document: document})
+	if err != nil {
+		return nil, &ConfigError{Config: name, Err: fmt.Errorf("parsing config %s: %v", name, err)}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := NewResult(name, v.schemaName)
+
+	root := v.bundle.rootPath()
+
+	for _, desc := range validated.Errors() {
+		result.Exceptions = append(result.Exceptions, v.exception(ctx, name, document, positions, root, "", desc))
+	}
+
+	if len(v.bundle.checked) > 0 {
+		result.Exceptions = v.checkFormats(ctx, name, document, positions, result.Exceptions)
+	}
+
+	if len(v.rules) > 0 {
+		result.Exceptions = append(result.Exceptions, v.checkRules(name, document, positions)...)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Exceptions) == 0 {
+		result.IsValid = true
+
+		return result, nil
+	}
+
+	sortExceptions(result.Exceptions)
+
+	// warnings of rules leave the document valid
+	result.IsValid = true
+	for _, e := range result.Exceptions {
+		if e.IsError() {
+			result.IsValid = false
+		}
+	}
+
+	if v.maxErrors > 0 && len(result.Exceptions) > v.maxErrors {
+		result.Exceptions = result.Exceptions[:v.maxErrors]
+	}
+
+	return result, nil
+}
+
+// sortExceptions puts exceptions in the order of the document. gojsonschema
+// walks the properties of objects in map order, so the order it reports
+// errors in changes from one run to the next.
+func sortExceptions(exceptions []ExceptionDetail) {
+	sort.SliceStable(exceptions, func(i, j int) bool {
+		a, b := exceptions[i], exceptions[j]
+
+		switch {
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Column != b.Column:
+			return a.Column < b.Column
+		case a.Pointer != b.Pointer:
+			return a.Pointer < b.Pointer
+		case a.Type != b.Type:
+			return a.Type < b.Type
+		}
+
+		return a.ErrorString < b.ErrorString
+	})
+}
+
+// exception describes desc, raised by the schema at from while validating
+// the instance at base, a JSON Pointer into document. Errors of anyOf and
+// oneOf get the errors of their branches as causes when the Validator was
+// built WithCauses.
+func (v *Validator) exception(ctx context.Context, name string, document interface{}, positions *positionIndex, from schemaPath, base string, desc gojsonschema.ResultError) ExceptionDetail {
+	pointer, key := errorPointer(desc)
+
+	exception := ExceptionDetail{
+		ErrorString: describe(v.locale, desc),
+		Path:        instanceContext(contextPointer(desc)),
+		Type:        desc.Type(),
+		Pointer:     pointer,
+		Value:       desc.Value(),
+		Params:      errorParams(desc),
+	}
+
+	// gojsonschema hands numbers over formatted as strings
+	if value, ok := resolvePointer(document, pointer); ok {
+		exception.Value = value
+	}
+
+	if span, ok := positions.lookup(pointer, key); ok {
+		exception.File = name
+		exception.setSpan(span)
+	}
+
+	instance := contextPointer(desc)
+
+	owner, keyword, ok := v.bundle.keywordOwner(from, strings.TrimPrefix(instance, base), desc)
+	if !ok {
+		return exception
+	}
+
+	exception.KeywordLocation, exception.AbsoluteKeywordLocation = owner.keywordLocation(keyword)
+	exception.units = v.bundle.units(exception.KeywordLocation, instance)
+
+	if v.causes && (keyword == "anyOf" || keyword == "oneOf") {
+		exception.Causes = v.branchErrors(ctx, name, document, positions, owner, keyword, instance)
+	}
+
+	return exception
+}
+
+// documentLoader is the gojsonschema.JSONLoader of an already decoded
+// document.
+type documentLoader struct {
+	document interface{}
+}
+
+// JsonSource returns the document.
+func (l *documentLoader) JsonSource() interface{} {
+	return l.document
+}
+
+// LoadJSON returns the document.
+func (l *documentLoader) LoadJSON() (interface{}, error) {
+	return l.document, nil
+}
+
+// JsonReference returns the reference of the document root.
+func (l *documentLoader) JsonReference() (gojsonreference.JsonReference, error) {
+	return gojsonreference.NewJsonReference("#")
+}
+
+// LoaderFactory returns gojsonschema's default loader factory.
+func (l *documentLoader) LoaderFactory() gojsonschema.JSONLoaderFactory {
+	return &gojsonschema.DefaultJSONLoaderFactory{}
+}
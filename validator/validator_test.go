@@ -0,0 +1,176 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testSchema = []byte(`{
+  "$schema": "http://json-schema.org/draft-04/schema#",
+  "properties": {
+    "name":    { "type": "string" },
+    "network": { "type": "string", "format": "cidr" },
+    "count":   { "type": "integer", "minimum": 1 }
+  },
+  "required": [ "name" ],
+  "type": "object"
+}`)
+
+func newTestValidator(t *testing.T, opts ...Option) *Validator {
+	v, err := New(append([]Option{WithSchemaBytes("test.json", testSchema)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestValidateBytes(t *testing.T) {
+	v := newTestValidator(t)
+
+	tests := []struct {
+		name     string
+		document string
+		valid    bool
+		errors   int
+	}{
+		{"json valid", `{"name": "a", "network": "10.0.0.0/8"}`, true, 0},
+		{"yaml valid", "name: a\nnetwork: 10.0.0.0/8\ncount: 3\n", true, 0},
+		{"yaml missing name", "network: 10.0.0.0/8\n", false, 1},
+		{"yaml bad cidr and count", "name: a\nnetwork: 10.0.0.0\ncount: 0\n", false, 2},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), test.name, []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+
+		if result.IsValid != test.valid || len(result.Exceptions) != test.errors {
+			t.Errorf("%s: expected valid=%v with %d errors, got valid=%v with %+v",
+				test.name, test.valid, test.errors, result.IsValid, result.Exceptions)
+		}
+
+		if result.Config != test.name || result.Schema != "test.json" {
+			t.Errorf("%s: unexpected result names %q and %q", test.name, result.Config, result.Schema)
+		}
+	}
+}
+
+func TestValidateReaderAndValue(t *testing.T) {
+	v := newTestValidator(t)
+
+	result, err := v.Validate(context.Background(), "reader", strings.NewReader("name: 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.IsValid || len(result.Exceptions) != 1 || result.Exceptions[0].Path != "(root).name" {
+		t.Errorf("unexpected reader result %+v", result)
+	}
+
+	result, err = v.ValidateValue(context.Background(), "value", map[string]interface{}{"name": "a", "count": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.IsValid {
+		t.Errorf("unexpected value result %+v", result)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	v := newTestValidator(t, WithMaxDocumentSize(8))
+
+	if _, err := v.ValidateBytes(context.Background(), "big", []byte(`{"name": "too long"}`)); err == nil {
+		t.Error("expected an error for a document over the size limit")
+	}
+
+	if _, err := v.Validate(context.Background(), "big", strings.NewReader(`{"name": "too long"}`)); err == nil {
+		t.Error("expected an error for a reader over the size limit")
+	}
+
+	v = newTestValidator(t, WithMaxErrors(1))
+
+	result, err := v.ValidateBytes(context.Background(), "many", []byte("network: nope\ncount: 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Exceptions) != 1 || result.Exceptions[0].Type != "required" {
+		t.Errorf("expected exceptions to be capped at the first one, got %+v", result.Exceptions)
+	}
+}
+
+func TestExceptionOrder(t *testing.T) {
+	v := newTestValidator(t)
+
+	// gojsonschema walks properties in map order; exceptions come out in
+	// the order of the document whatever the run
+	for i := 0; i < 20; i++ {
+		result, err := v.ValidateBytes(context.Background(), "order", []byte("name: web\ncount: 0\nnetwork: nope\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var pointers []string
+		for _, e := range result.Exceptions {
+			pointers = append(pointers, e.Pointer)
+		}
+
+		if !reflect.DeepEqual(pointers, []string{"/count", "/network"}) {
+			t.Fatalf("expected exceptions in document order, got %v", pointers)
+		}
+	}
+}
+
+func TestValidateLocale(t *testing.T) {
+	v := newTestValidator(t, WithLocale(LocaleMap{"required": "{{.property}} fehlt"}))
+
+	result, err := v.ValidateBytes(context.Background(), "locale", []byte("count: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Exceptions) != 1 || result.Exceptions[0].ErrorString != "name: name fehlt" {
+		t.Errorf("expected a localized message, got %+v", result.Exceptions)
+	}
+}
+
+func TestValidateCanceled(t *testing.T) {
+	v := newTestValidator(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := v.ValidateBytes(ctx, "canceled", []byte("name: a\n")); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewRequiresSchema(t *testing.T) {
+	if _, err := New(); err == nil {
+		t.Error("expected an error without a schema")
+	}
+
+	if _, err := New(WithSchemaBytes("broken.json", []byte(`{"type": 3}`))); err == nil {
+		t.Error("expected an error for an invalid schema")
+	}
+}
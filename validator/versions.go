@@ -0,0 +1,222 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+// The version keywords constrain semantic versions:
+//
+//	x-semver-range    the version instance is in the range, a blang/semver
+//	                  range expression such as ">=1.6.0 <1.9.0 || >=2.0.0"
+//	x-semver-gte-ref  the version instance is not older than the version
+//	                  the JSON Pointer names, e.g. "/etcd/version"
+//
+// Versions are parsed tolerantly: a leading v, as in Kraken's v1.7.3 tags,
+// is dropped and a missing minor or patch version is taken as 0. Instances
+// and referenced values that are not versions are left to the other
+// keywords, such as format.
+func init() {
+	extensionKeywords["x-semver-range"] = extensionKeyword{compile: compileVersionRange, check: checkVersionRange}
+	extensionKeywords["x-semver-gte-ref"] = extensionKeyword{compile: compileDataPointer, check: checkVersionGTERef}
+}
+
+// versionRange reports whether a version is in a range.
+type versionRange func(semver.Version) bool
+
+// versionOperators are the comparison operators of range expressions,
+// longest first so that >= is not taken for >.
+var versionOperators = []struct {
+	operator string
+	compare  func(int) bool
+}{
+	{">=", func(c int) bool { return c >= 0 }},
+	{"<=", func(c int) bool { return c <= 0 }},
+	{"!=", func(c int) bool { return c != 0 }},
+	{"==", func(c int) bool { return c == 0 }},
+	{">", func(c int) bool { return c > 0 }},
+	{"<", func(c int) bool { return c < 0 }},
+	{"=", func(c int) bool { return c == 0 }},
+}
+
+// parseVersion parses a semantic version tolerantly: surrounding space and
+// a leading v are dropped, and a missing minor or patch version is 0.
+func parseVersion(s string) (semver.Version, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+
+	core, rest := s, ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, rest = s[:i], s[i:]
+	}
+
+	if parts := strings.Count(core, ".") + 1; parts < 3 && core != "" {
+		core += strings.Repeat(".0", 3-parts)
+	}
+
+	return semver.Make(core + rest)
+}
+
+// parseVersionRange parses a range expression: comparators such as
+// >=1.6.0 or !=1.7.2, a version alone meaning =, separated by spaces when
+// all must hold and by || when either side may.
+func parseVersionRange(s string) (versionRange, error) {
+	var alternatives []versionRange
+
+	for _, alternative := range strings.Split(s, "||") {
+		tokens := strings.Fields(alternative)
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("empty range in %q", s)
+		}
+
+		var comparators []versionRange
+		for i := 0; i < len(tokens); i++ {
+			token := tokens[i]
+
+			// allow a space between an operator and its version
+			if isVersionOperator(token) && i+1 < len(tokens) {
+				i++
+				token += tokens[i]
+			}
+
+			comparator, err := parseComparator(token)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, comparator)
+		}
+
+		alternatives = append(alternatives, func(v semver.Version) bool {
+			for _, comparator := range comparators {
+				if !comparator(v) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	return func(v semver.Version) bool {
+		for _, alternative := range alternatives {
+			if alternative(v) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func isVersionOperator(token string) bool {
+	for _, o := range versionOperators {
+		if token == o.operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseComparator parses an operator and a version, such as >=1.6.0.
+func parseComparator(token string) (versionRange, error) {
+	compare := func(c int) bool { return c == 0 }
+	for _, o := range versionOperators {
+		if strings.HasPrefix(token, o.operator) {
+			token, compare = token[len(o.operator):], o.compare
+			break
+		}
+	}
+
+	version, err := parseVersion(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q in range: %v", token, err)
+	}
+
+	return func(v semver.Version) bool { return compare(v.Compare(version)) }, nil
+}
+
+// compileVersionRange accepts a range expression.
+func compileVersionRange(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected a range expression, got %v", value)
+	}
+
+	_, err := parseVersionRange(s)
+
+	return err
+}
+
+func checkVersionRange(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	version, err := parseVersion(s)
+	if err != nil {
+		return nil
+	}
+
+	expression := p.node["x-semver-range"].(string)
+	inRange, err := parseVersionRange(expression)
+	if err != nil || inRange(version) {
+		return nil
+	}
+
+	return []formatFailure{{
+		owner:     p,
+		keyword:   "x-semver-range",
+		pointer:   pointer,
+		value:     value,
+		errorType: "semver_range",
+		text:      "Version is not in the range {{.range}}",
+		params:    map[string]interface{}{"range": expression},
+	}}
+}
+
+func checkVersionGTERef(r *formatRun, p schemaPath, pointer string, value interface{}) []formatFailure {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	version, err := parseVersion(s)
+	if err != nil {
+		return nil
+	}
+
+	text, at, ok := r.referenced(pointer, p.node["x-semver-gte-ref"].(string))
+	if !ok {
+		return nil
+	}
+
+	minimum, err := parseVersion(text)
+	if err != nil || version.GTE(minimum) {
+		return nil
+	}
+
+	return []formatFailure{{
+		owner:     p,
+		keyword:   "x-semver-gte-ref",
+		pointer:   pointer,
+		value:     value,
+		errorType: "semver_gte_ref",
+		text:      "Version is older than {{.version}}, the version at {{.location}}",
+		params:    map[string]interface{}{"version": text, "location": at},
+	}}
+}
@@ -0,0 +1,133 @@
+// Copyright © 2017 Samsung CNCT
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVersionRanges(t *testing.T) {
+	tests := []struct {
+		expression string
+		version    string
+		in         bool
+	}{
+		{">=1.6.0 <1.9.0", "1.7.3", true},
+		{">=1.6.0 <1.9.0", "v1.7.3", true},
+		{">=1.6.0 <1.9.0", "1.9.0", false},
+		{">=1.6.0 <1.9.0", "1.5.9", false},
+		{">=1.6.0 <1.9.0", "1.9.0-alpha.1", true},
+		{">= 1.6 < 1.9", "1.8", true},
+		{">=v1.6.0", "v1.6.0", true},
+		{"<1.0.0 || >=2.0.0", "0.9.1", true},
+		{"<1.0.0 || >=2.0.0", "1.5.0", false},
+		{"<1.0.0 || >=2.0.0", "2.1.0", true},
+		{"1.7.3", "v1.7.3+coreos.0", true},
+		{"=1.7.3", "1.7.4", false},
+		{"!=1.7.2", "1.7.2", false},
+		{"!=1.7.2", "1.7.3", true},
+		{">1.7.0 <=1.7.3", "1.7.3", true},
+		{">1.7.0 <=1.7.3", "1.7.0", false},
+	}
+
+	for _, test := range tests {
+		inRange, err := parseVersionRange(test.expression)
+		if err != nil {
+			t.Errorf("%q: %v", test.expression, err)
+			continue
+		}
+
+		version, err := parseVersion(test.version)
+		if err != nil {
+			t.Errorf("%q: %v", test.version, err)
+			continue
+		}
+
+		if in := inRange(version); in != test.in {
+			t.Errorf("%s in %q: expected %v, got %v", test.version, test.expression, test.in, in)
+		}
+	}
+
+	for _, expression := range []string{"", ">=1.6.0 ||", ">=one", "~1.6", ">=1.6.0.1"} {
+		if _, err := parseVersionRange(expression); err == nil {
+			t.Errorf("%q: expected an invalid range", expression)
+		}
+	}
+}
+
+func TestVersionKeywords(t *testing.T) {
+	v, err := New(WithSchemaBytes("versions.json", []byte(`{
+		"properties": {
+			"etcd": { "properties": { "version": { "format": "semver" } } },
+			"kubeConfig": {
+				"properties": {
+					"version": {
+						"type": "string",
+						"x-semver-range": ">=1.6.0 <1.9.0",
+						"x-semver-gte-ref": "/etcd/version"
+					}
+				}
+			}
+		}
+	}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		document string
+		errors   []string
+	}{
+		{`{"etcd": {"version": "1.6.0"}, "kubeConfig": {"version": "v1.7.3"}}`, nil},
+		{`{"kubeConfig": {"version": "v1.9.1"}}`,
+			[]string{"semver_range kubeConfig.version: Version is not in the range >=1.6.0 <1.9.0"}},
+		{`{"etcd": {"version": "1.8.0"}, "kubeConfig": {"version": "v1.7.3"}}`,
+			[]string{"semver_gte_ref kubeConfig.version: Version is older than 1.8.0, the version at /etcd/version"}},
+		{`{"etcd": {"version": "1.8.0"}, "kubeConfig": {"version": "1.5"}}`,
+			[]string{
+				"semver_gte_ref kubeConfig.version: Version is older than 1.8.0, the version at /etcd/version",
+				"semver_range kubeConfig.version: Version is not in the range >=1.6.0 <1.9.0",
+			}},
+
+		// what is not a version is left to the other keywords
+		{`{"etcd": {"version": "latest"}, "kubeConfig": {"version": "v1.7.3"}}`,
+			[]string{"format etcd.version: Does not match format 'semver'"}},
+		{`{"kubeConfig": {"version": "latest"}}`, nil},
+		{`{"kubeConfig": {"version": 1.7}}`, []string{"invalid_type kubeConfig.version: Invalid type. Expected: string, given: number"}},
+	}
+
+	for _, test := range tests {
+		result, err := v.ValidateBytes(context.Background(), "config.json", []byte(test.document))
+		if err != nil {
+			t.Errorf("%s: %v", test.document, err)
+			continue
+		}
+
+		var errors []string
+		for _, e := range result.Exceptions {
+			errors = append(errors, e.Type+" "+e.ErrorString)
+		}
+
+		if strings.Join(errors, "\n") != strings.Join(test.errors, "\n") || result.IsValid != (len(test.errors) == 0) {
+			t.Errorf("%s:\nexpected %v\ngot      %v", test.document, test.errors, errors)
+		}
+	}
+
+	if _, err := New(WithSchemaBytes("bad.json", []byte(`{"x-semver-range": ">=1.6 <one"}`))); err == nil {
+		t.Error("expected an invalid range to fail the schema")
+	}
+}